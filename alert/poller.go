@@ -0,0 +1,74 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"aki.telegram.bot.fxrate/tools"
+)
+
+// DefaultInterval 默认检查间隔
+const DefaultInterval = 2 * time.Minute
+
+// Notifier 发送触发通知；返回 error 时提醒保持未触发，下一轮再试，包装了 ErrPermanent 的则删除该提醒
+type Notifier func(ctx context.Context, ev *Event) error
+
+// ErrPermanent 重试也不会成功的发送失败（如机器人被移出群、会话已删除）
+var ErrPermanent = errors.New("alert: permanent send failure")
+
+// Run 每隔 interval 检查一次全部提醒，直到 ctx 结束
+func Run(ctx context.Context, s *Store, interval time.Duration, notify Notifier) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		checkAll(ctx, s, notify)
+	}
+}
+
+// checkAll 检查全部提醒，同一轮内每个来源只取一次快照
+func checkAll(ctx context.Context, s *Store, notify Notifier) {
+	alerts, err := s.All()
+	if err != nil {
+		tools.LogError("alert: 读取提醒失败: %v", err)
+		return
+	}
+	r := newRound(ctx)
+	for i := range alerts {
+		check(r, s, &alerts[i], notify)
+	}
+}
+
+// check 未触发且满足条件时通知并记录触发；已触发且满足重置条件时重置
+func check(r *round, s *Store, a *Alert, notify Notifier) {
+	v, ev := r.eval(a)
+	switch {
+	case v == hit && !a.Fired:
+		if err := notify(r.ctx, ev); err != nil {
+			tools.LogError("alert: #%d 通知失败: %v", a.ID, err)
+			if errors.Is(err, ErrPermanent) {
+				// 会话已经收不到消息，留着只会每轮重试
+				if err := s.Delete(a.ID); err != nil && !errors.Is(err, ErrNotFound) {
+					tools.LogError("alert: #%d 删除失败: %v", a.ID, err)
+				}
+			}
+			return
+		}
+		a.fire(ev, r.now)
+	case v == rearm && a.Fired:
+		a.Fired = false
+	default:
+		return
+	}
+	if err := s.Update(a); err != nil && !errors.Is(err, ErrNotFound) {
+		tools.LogError("alert: #%d 保存失败: %v", a.ID, err)
+	}
+}
@@ -0,0 +1,49 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"aki.telegram.bot.fxrate/bank"
+)
+
+func TestCheckNotifyFailure(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		deleted bool
+	}{
+		{"临时失败保持未触发，下一轮重试", errors.New("timeout"), false},
+		{"永久失败删除提醒", fmt.Errorf("%w: forbidden", ErrPermanent), true},
+	}
+	for _, tt := range tests {
+		s := openTemp(t)
+		a := &Alert{ChatID: 1, Bank: "boc", Code: "USD", Field: bank.SellSpot, Op: Below, Threshold: dec("710")}
+		if err := s.Add(a); err != nil {
+			t.Fatal(err)
+		}
+		calls := 0
+		notify := func(context.Context, *Event) error {
+			calls++
+			return tt.err
+		}
+		check(testRound(boc(sell("709"))), s, a, notify)
+
+		saved, err := s.Get(a.ID)
+		if tt.deleted {
+			if !errors.Is(err, ErrNotFound) {
+				t.Errorf("%s: Get = %+v, %v, want ErrNotFound", tt.name, saved, err)
+			}
+			continue
+		}
+		if err != nil || saved.Fired {
+			t.Errorf("%s: saved = %+v, %v, want kept and not fired", tt.name, saved, err)
+		}
+		check(testRound(boc(sell("709"))), s, a, notify)
+		if calls != 2 {
+			t.Errorf("%s: notified %d times, want a retry", tt.name, calls)
+		}
+	}
+}
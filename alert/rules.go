@@ -0,0 +1,239 @@
+package alert
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"aki.telegram.bot.fxrate/bank"
+	"aki.telegram.bot.fxrate/history"
+	"aki.telegram.bot.fxrate/tools"
+)
+
+// Event 一次触发的详情
+type Event struct {
+	Alert   *Alert
+	Bank    string          // 触发的来源
+	Quote   *bank.Quote     // 触发来源的当前牌价
+	Value   decimal.Decimal // 当前价，每100外币
+	Ref     decimal.Decimal // 对照值：Move 为基准价，Best 为此前最优，Beat 为对比来源的价格
+	RefBank string          // Best 为此前最优所在来源，Beat 为对比来源
+	RefTime time.Time       // Best 为此前最优的发布时间
+}
+
+// Change Move 的涨跌幅（%）
+func (e *Event) Change() decimal.Decimal {
+	if e.Ref.IsZero() {
+		return decimal.Zero
+	}
+	return e.Value.Sub(e.Ref).Div(e.Ref).Mul(decimal.NewFromInt(100))
+}
+
+// verdict 一次评估的结论
+type verdict int
+
+const (
+	idle  verdict = iota // 无变化（含数据不足）
+	hit                  // 满足条件
+	rearm                // 已触发的提醒可以重置
+)
+
+// Hit Threshold 提醒：当前价格 v（每100外币）是否满足条件
+func (a *Alert) Hit(v decimal.Decimal) bool {
+	if a.Op == Above {
+		return v.GreaterThanOrEqual(a.Threshold)
+	}
+	return v.LessThanOrEqual(a.Threshold)
+}
+
+// RearmLine Threshold 提醒触发后价格须越过的重置线
+func (a *Alert) RearmLine() decimal.Decimal {
+	off := a.Threshold.Mul(Rearm)
+	if a.Op == Above {
+		return a.Threshold.Sub(off)
+	}
+	return a.Threshold.Add(off)
+}
+
+// Rearmed Threshold 提醒已触发后是否可以重置
+func (a *Alert) Rearmed(v decimal.Decimal) bool {
+	if a.Op == Above {
+		return v.LessThan(a.RearmLine())
+	}
+	return v.GreaterThan(a.RearmLine())
+}
+
+// fire 记录一次触发；Move 以触发价作为新基准，之后相对它再涨跌同样比例会再次提醒
+func (a *Alert) fire(ev *Event, now time.Time) {
+	a.FiredAt = now
+	if a.Kind == Move {
+		a.Baseline = ev.Value
+		return
+	}
+	a.Fired = true
+}
+
+// round 一轮检查：同一来源只取一次快照，多条提醒共用
+type round struct {
+	ctx   context.Context
+	now   time.Time
+	snaps map[string]*bank.Snapshot
+}
+
+func newRound(ctx context.Context) *round {
+	return &round{ctx: ctx, now: time.Now(), snaps: make(map[string]*bank.Snapshot)}
+}
+
+// quote 取某来源某币种的当前牌价某项（每100外币）
+func (r *round) quote(key, code string, f bank.Field) (*bank.Quote, decimal.Decimal, bool) {
+	snap, seen := r.snaps[key]
+	if !seen {
+		if p, ok := bank.Lookup(key); ok {
+			var err error
+			if snap, err = p.Snapshot(r.ctx); err != nil {
+				tools.LogError("alert: %s 获取失败: %v", p.Name(), err)
+			}
+		}
+		r.snaps[key] = snap
+	}
+	if snap == nil {
+		return nil, decimal.Zero, false
+	}
+	found, ok := snap.Find(code)
+	if !ok {
+		return nil, decimal.Zero, false
+	}
+	q := *found
+	if q.ReleaseTime.IsZero() {
+		q.ReleaseTime = snap.ReleaseTime
+	}
+	v := q.Per(f, 100)
+	return &q, v.Decimal, v.Valid
+}
+
+// eval 评估一条提醒
+func (r *round) eval(a *Alert) (verdict, *Event) {
+	switch a.Kind {
+	case Threshold:
+		q, v, ok := r.quote(a.Bank, a.Code, a.Field)
+		if !ok {
+			return idle, nil
+		}
+		switch {
+		case a.Hit(v):
+			return hit, &Event{Alert: a, Bank: a.Bank, Quote: q, Value: v}
+		case a.Rearmed(v):
+			return rearm, nil
+		}
+	case Move:
+		q, v, ok := r.quote(a.Bank, a.Code, a.Field)
+		if !ok || !a.Baseline.IsPositive() {
+			return idle, nil
+		}
+		ev := &Event{Alert: a, Bank: a.Bank, Quote: q, Value: v, Ref: a.Baseline}
+		if ev.Change().Abs().GreaterThanOrEqual(a.Threshold) {
+			return hit, ev
+		}
+	case Beat:
+		return r.evalBeat(a)
+	case Best:
+		return r.evalBest(a)
+	}
+	return idle, nil
+}
+
+// evalBeat a.Bank 比 a.Other 优出超过 a.Threshold 时触发，优势回落到一半以下时重置
+func (r *round) evalBeat(a *Alert) (verdict, *Event) {
+	q, v, ok := r.quote(a.Bank, a.Code, a.Field)
+	if !ok {
+		return idle, nil
+	}
+	_, ref, ok := r.quote(a.Other, a.Code, a.Field)
+	if !ok {
+		return idle, nil
+	}
+	adv := v.Sub(ref)
+	if !Higher(a.Field) {
+		adv = adv.Neg()
+	}
+	switch {
+	case adv.GreaterThan(a.Threshold):
+		return hit, &Event{Alert: a, Bank: a.Bank, Quote: q, Value: v, Ref: ref, RefBank: a.Other}
+	case adv.LessThan(a.Threshold.Div(decimal.NewFromInt(2))):
+		return rearm, nil
+	}
+	return idle, nil
+}
+
+// evalBest 当前价优于近 a.Days 天内（a.Bank 为空时为所有来源）此前的最优记录时触发；
+// 当前最优比区间最优差出 Rearm 比例后重置
+func (r *round) evalBest(a *Alert) (verdict, *Event) {
+	store := history.Default()
+	if store == nil {
+		return idle, nil
+	}
+	keys := []string{a.Bank}
+	if a.Bank == "" {
+		keys = keys[:0]
+		for _, p := range bank.Providers() {
+			keys = append(keys, p.Key())
+		}
+	}
+	higher := Higher(a.Field)
+	better := func(x, y decimal.Decimal) bool {
+		if higher {
+			return x.GreaterThan(y)
+		}
+		return x.LessThan(y)
+	}
+
+	var (
+		cur   *Event // 当前各来源中最优的
+		prior *Event // 区间内此前的最优
+	)
+	from := r.now.AddDate(0, 0, -a.Days)
+	for _, key := range keys {
+		q, v, ok := r.quote(key, a.Code, a.Field)
+		if !ok {
+			continue
+		}
+		if cur == nil || better(v, cur.Value) {
+			cur = &Event{Alert: a, Bank: key, Quote: q, Value: v}
+		}
+		records, err := store.Range(key, a.Code, from, r.now)
+		if err != nil {
+			tools.LogError("alert: %s %s 读取历史失败: %v", key, a.Code, err)
+			continue
+		}
+		// 最近一条就是当前这次发布的，不算作"此前"
+		if n := len(records); n > 0 && sameRelease(&records[n-1], q, v, a.Field) {
+			records = records[:n-1]
+		}
+		for _, pt := range history.Series(records, a.Field) {
+			if prior == nil || better(pt.Value, prior.Value) {
+				prior = &Event{Bank: key, Value: pt.Value, RefTime: pt.Time}
+			}
+		}
+	}
+	if cur == nil || prior == nil {
+		return idle, nil
+	}
+	if better(cur.Value, prior.Value) {
+		cur.Ref, cur.RefBank, cur.RefTime = prior.Value, prior.Bank, prior.RefTime
+		return hit, cur
+	}
+	if prior.Value.Sub(cur.Value).Abs().GreaterThan(prior.Value.Mul(Rearm)) {
+		return rearm, nil
+	}
+	return idle, nil
+}
+
+// sameRelease 历史记录 rec 是否就是当前牌价 q 的这次发布
+func sameRelease(rec *history.Record, q *bank.Quote, v decimal.Decimal, f bank.Field) bool {
+	if !q.ReleaseTime.IsZero() {
+		return !rec.ReleaseTime.Before(q.ReleaseTime)
+	}
+	rv := rec.Quote().Per(f, 100)
+	return rv.Valid && rv.Decimal.Equal(v)
+}
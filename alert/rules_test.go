@@ -0,0 +1,162 @@
+package alert
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	bolt "go.etcd.io/bbolt"
+
+	"aki.telegram.bot.fxrate/bank"
+)
+
+func dec(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+func openTemp(t *testing.T) *Store {
+	t.Helper()
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "alerts.db"), 0o600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	s, err := New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// testRound 一轮检查，各来源的美元牌价（每100外币）事先给定，不去请求银行
+func testRound(prices map[string]bank.Quote) *round {
+	r := &round{ctx: context.Background(), now: time.Now(), snaps: map[string]*bank.Snapshot{}}
+	for key, q := range prices {
+		q.Code, q.Unit = "USD", 100
+		r.snaps[key] = &bank.Snapshot{Bank: key, Quotes: []bank.Quote{q}}
+	}
+	return r
+}
+
+func sell(v string) bank.Quote { return bank.Quote{SellSpot: decimal.NewNullDecimal(dec(v))} }
+func buy(v string) bank.Quote  { return bank.Quote{BuySpot: decimal.NewNullDecimal(dec(v))} }
+
+// step 一轮检查后期望的结果
+type step struct {
+	prices   map[string]bank.Quote
+	notified bool   // 本轮是否发出通知
+	fired    bool   // 本轮结束后是否处于已触发状态
+	baseline string // Move 本轮结束后的基准价，空表示不检查
+}
+
+// runSteps 依次执行各轮检查，核对通知与状态，并确认状态已写回存储
+func runSteps(t *testing.T, name string, a *Alert, steps []step) {
+	t.Helper()
+	s := openTemp(t)
+	a.ChatID = 1
+	if err := s.Add(a); err != nil {
+		t.Fatal(err)
+	}
+	for i, st := range steps {
+		notified := false
+		check(testRound(st.prices), s, a, func(context.Context, *Event) error {
+			notified = true
+			return nil
+		})
+		if notified != st.notified || a.Fired != st.fired {
+			t.Errorf("%s step %d: notified %v fired %v, want %v %v", name, i, notified, a.Fired, st.notified, st.fired)
+		}
+		if st.baseline != "" && !a.Baseline.Equal(dec(st.baseline)) {
+			t.Errorf("%s step %d: baseline %s, want %s", name, i, a.Baseline, st.baseline)
+		}
+		saved, err := s.Get(a.ID)
+		if err != nil || saved.Fired != a.Fired || !saved.Baseline.Equal(a.Baseline) {
+			t.Errorf("%s step %d: saved %+v, %v", name, i, saved, err)
+		}
+	}
+}
+
+func boc(q bank.Quote) map[string]bank.Quote { return map[string]bank.Quote{"boc": q} }
+
+func TestThresholdRearm(t *testing.T) {
+	// 710 跌破提醒：重置线 710 × 1.002 = 711.42
+	runSteps(t, "below", &Alert{Bank: "boc", Code: "USD", Field: bank.SellSpot, Op: Below, Threshold: dec("710")}, []step{
+		{prices: boc(sell("712")), notified: false, fired: false},
+		{prices: boc(sell("710")), notified: true, fired: true}, // 等于阈值即触发
+		{prices: boc(sell("709")), notified: false, fired: true},
+		{prices: boc(sell("711")), notified: false, fired: true},    // 回到阈值上方但未过重置线
+		{prices: boc(sell("711.42")), notified: false, fired: true}, // 恰在重置线上不算越过
+		{prices: boc(sell("711.43")), notified: false, fired: false},
+		{prices: boc(sell("709.9")), notified: true, fired: true},
+	})
+	// 720 升破提醒：重置线 720 × 0.998 = 718.56
+	runSteps(t, "above", &Alert{Bank: "boc", Code: "USD", Field: bank.BuySpot, Op: Above, Threshold: dec("720")}, []step{
+		{prices: boc(buy("721")), notified: true, fired: true},
+		{prices: boc(buy("719")), notified: false, fired: true},
+		{prices: boc(buy("718.56")), notified: false, fired: true},
+		{prices: boc(buy("718.5")), notified: false, fired: false},
+		{prices: boc(buy("720")), notified: true, fired: true},
+	})
+	// 缺价或来源没有该币种时保持原状
+	runSteps(t, "missing", &Alert{Bank: "boc", Code: "USD", Field: bank.SellSpot, Op: Below, Threshold: dec("710")}, []step{
+		{prices: boc(buy("700")), notified: false, fired: false},
+		{prices: map[string]bank.Quote{}, notified: false, fired: false},
+	})
+}
+
+func TestRearmLine(t *testing.T) {
+	tests := []struct {
+		op        Op
+		threshold string
+		want      string
+	}{
+		{Below, "710", "711.42"},
+		{Above, "720", "718.56"},
+		{Below, "4.70", "4.7094"},
+	}
+	for _, tt := range tests {
+		a := &Alert{Op: tt.op, Threshold: dec(tt.threshold)}
+		if got := a.RearmLine(); !got.Equal(dec(tt.want)) {
+			t.Errorf("RearmLine(%s %s) = %s, want %s", tt.op, tt.threshold, got, tt.want)
+		}
+	}
+}
+
+func TestMoveRebase(t *testing.T) {
+	// 相对 700 涨跌 0.3% 提醒，每次触发以触发价为新基准，不进入已触发状态
+	runSteps(t, "move", &Alert{Kind: Move, Bank: "boc", Code: "USD", Field: bank.SellSpot, Threshold: dec("0.3"), Baseline: dec("700")}, []step{
+		{prices: boc(sell("701")), notified: false, baseline: "700"},    // +0.143%
+		{prices: boc(sell("702.1")), notified: true, baseline: "702.1"}, // 恰好 +0.3%
+		{prices: boc(sell("702.5")), notified: false, baseline: "702.1"},
+		{prices: boc(sell("700")), notified: false, baseline: "702.1"},    // -0.299%
+		{prices: boc(sell("699.99")), notified: true, baseline: "699.99"}, // -0.3005%
+		{prices: boc(sell("699.99")), notified: false, baseline: "699.99"},
+	})
+	// 没有基准价时不评估
+	runSteps(t, "move without baseline", &Alert{Kind: Move, Bank: "boc", Code: "USD", Field: bank.SellSpot, Threshold: dec("0.3")}, []step{
+		{prices: boc(sell("800")), notified: false, baseline: "0"},
+	})
+}
+
+func TestBeatRearm(t *testing.T) {
+	both := func(bocSell, cmbSell string) map[string]bank.Quote {
+		return map[string]bank.Quote{"boc": sell(bocSell), "cmb": sell(cmbSell)}
+	}
+	// 卖出价越低越好：中行比招行低出 1 以上时提醒，优势回落到 0.5 以下才重置
+	runSteps(t, "beat sell", &Alert{Kind: Beat, Bank: "boc", Other: "cmb", Code: "USD", Field: bank.SellSpot, Threshold: dec("1")}, []step{
+		{prices: both("712", "712.5"), notified: false, fired: false},
+		{prices: both("711.5", "712.5"), notified: false, fired: false}, // 恰好 1 不算优出
+		{prices: both("711", "712.5"), notified: true, fired: true},
+		{prices: both("711.8", "712.5"), notified: false, fired: true},  // 优势 0.7，仍在一半以上
+		{prices: both("712", "712.5"), notified: false, fired: true},    // 恰好一半也不重置
+		{prices: both("712.1", "712.5"), notified: false, fired: false}, // 0.4
+		{prices: both("711.4", "712.5"), notified: true, fired: true},
+		{prices: both("713", "712.5"), notified: false, fired: false}, // 反过来落后也重置
+	})
+	// 买入价越高越好
+	runSteps(t, "beat buy", &Alert{Kind: Beat, Bank: "boc", Other: "cmb", Code: "USD", Field: bank.BuySpot, Threshold: dec("1")}, []step{
+		{prices: map[string]bank.Quote{"boc": buy("712"), "cmb": buy("710.5")}, notified: true, fired: true},
+		{prices: map[string]bank.Quote{"boc": buy("712")}, notified: false, fired: true}, // 对比来源缺价
+		{prices: map[string]bank.Quote{"boc": buy("711"), "cmb": buy("710.6")}, notified: false, fired: false},
+	})
+}
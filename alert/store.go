@@ -0,0 +1,122 @@
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/shopspring/decimal"
+	bolt "go.etcd.io/bbolt"
+
+	"aki.telegram.bot.fxrate/bank"
+	"aki.telegram.bot.fxrate/bucket"
+)
+
+// MaxPerChat 每个会话最多的提醒数
+const MaxPerChat = 50
+
+// ErrNotFound 提醒不存在（或已被删除）
+var ErrNotFound = bucket.ErrNotFound
+
+// ErrTooMany 会话内提醒数已达上限
+var ErrTooMany = fmt.Errorf("alert: at most %d alerts per chat", MaxPerChat)
+
+// Kind 提醒类型
+type Kind string
+
+const (
+	Threshold Kind = ""     // 价格越过固定阈值
+	Move      Kind = "move" // 相对基准价涨跌超过一定比例，触发后以触发价为新基准
+	Best      Kind = "best" // 创近 N 天最优（买入价最高、卖出价最低）
+	Beat      Kind = "beat" // 某银行比另一家银行优出一定价差
+)
+
+// Op 比较方向
+type Op string
+
+const (
+	Below Op = "<" // 价格跌破阈值时提醒
+	Above Op = ">" // 价格升破阈值时提醒
+)
+
+// Rearm 触发后价格须反向越过阈值这一比例才会重新提醒，避免在阈值附近来回波动时刷屏
+var Rearm = decimal.RequireFromString("0.002")
+
+// Alert 一条价格提醒
+type Alert struct {
+	ID        uint64          `json:"id"`
+	Kind      Kind            `json:"kind,omitempty"`
+	ChatID    int64           `json:"chat_id"`
+	ThreadID  int             `json:"thread_id,omitempty"`
+	UserID    int64           `json:"user_id"`
+	Bank      string          `json:"bank"` // Best 时为空表示任一来源
+	Code      string          `json:"code"`
+	Field     bank.Field      `json:"field"`
+	Op        Op              `json:"op,omitempty"`    // 仅 Threshold
+	Threshold decimal.Decimal `json:"threshold"`       // Threshold: 价格；Move: 百分比；Beat: 价差。均按每100外币
+	Baseline  decimal.Decimal `json:"baseline"`        // Move 的基准价
+	Other     string          `json:"other,omitempty"` // Beat 的对比来源
+	Days      int             `json:"days,omitempty"`  // Best 的回看天数
+	Fired     bool            `json:"fired"`           // 已触发，等待重置
+	FiredAt   time.Time       `json:"fired_at,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// Higher 该项牌价是否越高越好：买入价（银行买外币）越高越好，卖出价越低越好
+func Higher(f bank.Field) bool {
+	return f == bank.BuySpot || f == bank.BuyCash
+}
+
+// Store 提醒存储
+type Store struct {
+	items *bucket.Store[Alert]
+}
+
+var defaultStore atomic.Pointer[Store]
+
+// SetDefault 设置全局提醒存储
+func SetDefault(s *Store) { defaultStore.Store(s) }
+
+// Default 全局提醒存储，未启用时为 nil
+func Default() *Store { return defaultStore.Load() }
+
+// New 在 db 中建好提醒所需的 bucket
+func New(db *bolt.DB) (*Store, error) {
+	items, err := bucket.New[Alert](db, "alerts")
+	if err != nil {
+		return nil, err
+	}
+	return &Store{items: items}, nil
+}
+
+// Add 保存新提醒并分配 ID
+func (s *Store) Add(a *Alert) error {
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now()
+	}
+	err := s.items.Insert(a, func(id uint64) { a.ID = id }, MaxPerChat, func(o *Alert) bool { return o.ChatID == a.ChatID })
+	if errors.Is(err, bucket.ErrLimit) {
+		return ErrTooMany
+	}
+	return err
+}
+
+// Update 覆盖已有提醒；提醒已被删除时返回 ErrNotFound，不会重新写回
+func (s *Store) Update(a *Alert) error { return s.items.Update(a.ID, a) }
+
+// Get 按 ID 取提醒
+func (s *Store) Get(id uint64) (*Alert, error) { return s.items.Get(id) }
+
+// Delete 删除提醒
+func (s *Store) Delete(id uint64) error { return s.items.Delete(id) }
+
+// All 全部提醒，按 ID 升序
+func (s *Store) All() ([]Alert, error) {
+	return s.items.Filter(func(*Alert) bool { return true })
+}
+
+// ByChat 某会话的提醒，按 ID 升序
+func (s *Store) ByChat(chatID int64) ([]Alert, error) {
+	return s.items.Filter(func(a *Alert) bool { return a.ChatID == chatID })
+}
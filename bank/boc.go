@@ -7,8 +7,11 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
+
+	"aki.telegram.bot.fxrate/currency"
 )
 
 const (
@@ -18,6 +21,7 @@ const (
 
 type BOCRate struct {
 	Name        string // 币种中文名
+	Symbol      string // 币种代码（由中文名识别，识别不出为空）
 	BuySpot     string // 现汇买入价
 	BuyCash     string // 现钞买入价
 	SellSpot    string // 现汇卖出价
@@ -26,76 +30,56 @@ type BOCRate struct {
 	ReleaseTime string // 汇率发布时间
 }
 
-var codeToCN = map[string]string{
-	"usd": "美元",
-	"hkd": "港币",
-	"eur": "欧元",
-	"gbp": "英镑",
-	"jpy": "日元",
-	"aud": "澳大利亚元",
-	"cad": "加拿大元",
-	"sgd": "新加坡元",
-	"nzd": "新西兰元",
-	"chf": "瑞士法郎",
-	"thb": "泰国铢",
-	"twd": "新台币",
-	"krw": "韩国元",
-	"php": "菲律宾比索",
-	"idr": "印尼卢比",
-	"inr": "印度卢比",
-	"rub": "卢布",
-	"zar": "南非兰特",
-	"aed": "阿联酋迪拉姆",
-	"sar": "沙特里亚尔",
-	"huf": "匈牙利福林",
-	"czk": "捷克克朗",
-	"sek": "瑞典克朗",
-	"dkk": "丹麦克朗",
-	"nok": "挪威克朗",
-	"mxn": "墨西哥比索",
-	"ils": "以色列谢克尔",
-	"try": "土耳其里拉",
-	"brl": "巴西里亚尔",
-	"vnd": "越南盾",
-	"bnd": "文莱元",
-	"kwd": "科威特第纳尔",
-	"npr": "尼泊尔卢比",
-	"pkr": "巴基斯坦卢比",
-	"qar": "卡塔尔里亚尔",
-	"mnt": "蒙古图格里克",
-	"mop": "澳门元",
-	// 常见对“人民币”的别称，用于识别 CNY（表中无独立行）
-	"cny":      "人民币",
-	"rmb":      "人民币",
-	"renminbi": "人民币",
+// BOCSnapshot 一次抓取得到的整张牌价表
+type BOCSnapshot struct {
+	Rates       []BOCRate
+	ReleaseTime string    // 页面发布时间（取各行最新）
+	FetchedAt   time.Time // 抓取时间
 }
 
 // GetBOCRate 通过“代码/中文名/模糊匹配”获取单币种牌价
 // 返回：rate，found，error
 func GetBOCRate(ctx context.Context, query string) (*BOCRate, bool, error) {
-	doc, err := fetchBOCDoc(ctx)
+	snap, err := GetBOCSnapshot(ctx)
 	if err != nil {
 		return nil, false, err
 	}
+	rate, found := snap.Find(query)
+	return rate, found, nil
+}
+
+// Find 在快照中查找单币种
+func (s *BOCSnapshot) Find(query string) (*BOCRate, bool) {
+	return findRow(s.Rates, query, func(r *BOCRate) string { return r.Symbol })
+}
+
+// GetBOCSnapshot 获取整张牌价表（经缓存，返回值只读）
+func GetBOCSnapshot(ctx context.Context) (*BOCSnapshot, error) {
+	return cached(ctx, "boc", fetchBOCSnapshot)
+}
+
+func fetchBOCSnapshot(ctx context.Context) (*BOCSnapshot, error) {
+	doc, err := fetchBOCDoc(ctx)
+	if err != nil {
+		return nil, err
+	}
 	table := locateRateTable(doc)
 	if table == nil {
-		return nil, false, fmt.Errorf("未在页面上找到牌价表")
+		return nil, fmt.Errorf("未在页面上找到牌价表")
 	}
 
-	target := normalizeQuery(query)
-	var rate *BOCRate
-
-	table.Find("tr").EachWithBreak(func(i int, tr *goquery.Selection) bool {
+	var rates []BOCRate
+	table.Find("tr").Each(func(i int, tr *goquery.Selection) {
 		if i == 0 {
-			return true // 跳过表头
+			return // 跳过表头
 		}
 		tds := tr.Find("td")
 		if tds.Length() < 2 {
-			return true
+			return
 		}
 		name := strings.TrimSpace(tds.Eq(0).Text())
-		if !matchCurrency(name, target) {
-			return true
+		if name == "" {
+			return
 		}
 
 		buySpot := getTD(tds, 1)
@@ -113,49 +97,27 @@ func GetBOCRate(ctx context.Context, query string) (*BOCRate, bool, error) {
 			ts = strings.TrimSpace(ts + " " + timeStr)
 		}
 
-		rate = &BOCRate{
+		rates = append(rates, BOCRate{
 			Name:        name,
+			Symbol:      currency.Code(name),
 			BuySpot:     nz(buySpot, "-"),
 			BuyCash:     nz(buyCash, "-"),
 			SellSpot:    nz(sellSpot, "-"),
 			SellCash:    nz(sellCash, "-"),
 			BankRate:    nz(refRate, "-"),
 			ReleaseTime: nz(ts, "-"),
-		}
-		return false
+		})
 	})
 
-	if rate == nil {
-		return nil, false, nil
-	}
-	return rate, true, nil
-}
-
-func normalizeQuery(q string) string {
-	q = strings.TrimSpace(q)
-	if q == "" {
-		return q
-	}
-	lq := strings.ToLower(q)
-	lq = strings.ReplaceAll(lq, "_", "")
-	lq = strings.ReplaceAll(lq, "-", "")
-	lq = strings.ReplaceAll(lq, "/", "")
-	if cn, ok := codeToCN[lq]; ok {
-		return cn
+	releaseTimes := make([]string, 0, len(rates))
+	for _, r := range rates {
+		releaseTimes = append(releaseTimes, r.ReleaseTime)
 	}
-	return q
-}
-
-func matchCurrency(name string, target string) bool {
-	name = strings.TrimSpace(name)
-	target = strings.TrimSpace(target)
-	if name == "" || target == "" {
-		return false
-	}
-	if strings.EqualFold(name, target) {
-		return true
-	}
-	return strings.Contains(name, target) || strings.Contains(target, name)
+	return &BOCSnapshot{
+		Rates:       rates,
+		ReleaseTime: latestTime(releaseTimes...),
+		FetchedAt:   time.Now(),
+	}, nil
 }
 
 func getTD(tds *goquery.Selection, idx int) string {
@@ -165,6 +127,21 @@ func getTD(tds *goquery.Selection, idx int) string {
 	return strings.TrimSpace(tds.Eq(idx).Text())
 }
 
+// latestTime 返回同一格式时间串中最新的一个（按字典序），忽略 "-"
+func latestTime(ts ...string) string {
+	latest := ""
+	for _, t := range ts {
+		t = strings.TrimSpace(t)
+		if t == "" || t == "-" {
+			continue
+		}
+		if t > latest {
+			latest = t
+		}
+	}
+	return nz(latest, "-")
+}
+
 func nz(s, def string) string {
 	if strings.TrimSpace(s) == "" {
 		return def
@@ -221,3 +198,42 @@ func locateRateTable(doc *goquery.Document) *goquery.Selection {
 	})
 	return found
 }
+
+// ---- Provider ----
+
+func init() { Register(bocProvider{}) }
+
+type bocProvider struct{}
+
+func (bocProvider) Key() string  { return "boc" }
+func (bocProvider) Name() string { return "中国银行" }
+
+func (bocProvider) Currencies() []string {
+	return []string{
+		"AED", "AUD", "BND", "BRL", "CAD", "CHF", "CZK", "DKK", "EUR", "GBP", "HKD", "HUF", "IDR",
+		"ILS", "INR", "JPY", "KRW", "KWD", "MNT", "MOP", "MXN", "MYR", "NOK", "NPR", "NZD", "PHP",
+		"PKR", "QAR", "RUB", "SAR", "SEK", "SGD", "THB", "TRY", "TWD", "USD", "VND", "ZAR",
+	}
+}
+
+func (bocProvider) Snapshot(ctx context.Context) (*Snapshot, error) {
+	snap, err := GetBOCSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Quote, 0, len(snap.Rates))
+	for _, r := range snap.Rates {
+		out = append(out, Quote{
+			Code:        r.Symbol,
+			Name:        r.Name,
+			Unit:        100,
+			BuySpot:     parsePrice(r.BuySpot),
+			BuyCash:     parsePrice(r.BuyCash),
+			SellSpot:    parsePrice(r.SellSpot),
+			SellCash:    parsePrice(r.SellCash),
+			Middle:      parsePrice(r.BankRate),
+			ReleaseTime: parseReleaseTime(r.ReleaseTime),
+		})
+	}
+	return newSnapshot("boc", out, snap.ReleaseTime, snap.FetchedAt), nil
+}
@@ -0,0 +1,174 @@
+package bank
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"aki.telegram.bot.fxrate/tools"
+)
+
+// 内存牌价缓存：
+//   - 每家银行单独的 TTL（招行更新很勤，广发很慢）
+//   - 同一 key 并发请求只发起一次抓取（singleflight）
+//   - 上游出错时在 maxStale 内回退到旧数据
+
+const (
+	defaultCacheTTL = 2 * time.Minute
+	maxStale        = 6 * time.Hour
+	fetchTimeout    = 30 * time.Second
+)
+
+var cacheTTL = map[string]time.Duration{
+	"boc":      2 * time.Minute,
+	"cib":      2 * time.Minute,
+	"cmb":      30 * time.Second,
+	"cgb":      10 * time.Minute,
+	"citic":    2 * time.Minute,
+	"unionpay": time.Hour,
+}
+
+type cacheEntry struct {
+	val       any
+	fetchedAt time.Time
+}
+
+type inflight struct {
+	done chan struct{}
+	val  any
+	err  error
+}
+
+var (
+	cacheMu   sync.Mutex
+	entries   = map[string]*cacheEntry{}
+	inflights = map[string]*inflight{}
+)
+
+// ConfigureCache 解析形如 "default=2m,cmb=30s,cgb=10m" 的 TTL 配置，0 表示不缓存
+func ConfigureCache(spec string) error {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil
+	}
+	parsed := map[string]time.Duration{}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(item, "=")
+		if !ok {
+			return fmt.Errorf("cache ttl: %q 缺少 '='", item)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(v))
+		if err != nil || d < 0 {
+			return fmt.Errorf("cache ttl: %q 不是有效时长", v)
+		}
+		parsed[strings.ToLower(strings.TrimSpace(k))] = d
+	}
+
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	for k, d := range parsed {
+		cacheTTL[k] = d
+	}
+	return nil
+}
+
+// CacheTTL 返回某来源当前的缓存时长
+func CacheTTL(key string) time.Duration {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	return ttlLocked(key)
+}
+
+func ttlLocked(key string) time.Duration {
+	if d, ok := cacheTTL[key]; ok {
+		return d
+	}
+	if d, ok := cacheTTL["default"]; ok {
+		return d
+	}
+	return defaultCacheTTL
+}
+
+// InvalidateCache 丢弃某来源的缓存，下次请求强制抓取；key 为空时清空全部
+func InvalidateCache(key string) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	if key == "" {
+		entries = map[string]*cacheEntry{}
+		return
+	}
+	delete(entries, key)
+}
+
+// Refresh 手动刷新：来源 key（优惠方案按其基础来源）的缓存已超过 minAge 时丢弃，
+// 下次请求即重新抓取；minAge 用来防止连点刷新反复请求银行
+func Refresh(key string, minAge time.Duration) {
+	key = strings.ToLower(strings.TrimSpace(key))
+	if prog, ok := LookupProgramme(key); ok {
+		key = prog.Base
+	}
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	if entry := entries[key]; entry != nil && time.Since(entry.fetchedAt) >= minAge {
+		delete(entries, key)
+	}
+}
+
+// cached 以 key 缓存 fetch 的结果
+func cached[T any](ctx context.Context, key string, fetch func(context.Context) (T, error)) (T, error) {
+	var zero T
+
+	cacheMu.Lock()
+	ttl := ttlLocked(key)
+	entry := entries[key]
+	if entry != nil && ttl > 0 && time.Since(entry.fetchedAt) < ttl {
+		cacheMu.Unlock()
+		return entry.val.(T), nil
+	}
+	call, running := inflights[key]
+	if !running {
+		call = &inflight{done: make(chan struct{})}
+		inflights[key] = call
+		// 抓取不跟随单个调用方取消，避免一个人撤回导致其他等待者一起失败
+		go runFetch(context.WithoutCancel(ctx), key, call, func(c context.Context) (any, error) { return fetch(c) })
+	}
+	cacheMu.Unlock()
+
+	select {
+	case <-call.done:
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+
+	if call.err == nil {
+		return call.val.(T), nil
+	}
+	// 上游出错：有不太旧的数据就先用着
+	if entry != nil && time.Since(entry.fetchedAt) < maxStale {
+		tools.LogError("%s 抓取失败，使用 %s 前的缓存: %v", key, time.Since(entry.fetchedAt).Round(time.Second), call.err)
+		return entry.val.(T), nil
+	}
+	return zero, call.err
+}
+
+func runFetch(ctx context.Context, key string, call *inflight, fetch func(context.Context) (any, error)) {
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+
+	val, err := fetch(ctx)
+
+	cacheMu.Lock()
+	if err == nil {
+		entries[key] = &cacheEntry{val: val, fetchedAt: time.Now()}
+	}
+	call.val, call.err = val, err
+	delete(inflights, key)
+	cacheMu.Unlock()
+	close(call.done)
+}
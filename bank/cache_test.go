@@ -0,0 +1,252 @@
+package bank
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeFetcher 记录调用次数，返回当前设置的值；err 非 nil 时返回错误
+type fakeFetcher struct {
+	calls atomic.Int32
+	mu    sync.Mutex
+	val   string
+	err   error
+}
+
+func (f *fakeFetcher) set(val string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.val, f.err = val, err
+}
+
+func (f *fakeFetcher) fetch(context.Context) (string, error) {
+	f.calls.Add(1)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.val, f.err
+}
+
+// useCacheKey 为测试设置 key 的 TTL，结束时清掉缓存与配置
+func useCacheKey(t *testing.T, key string, ttl time.Duration) {
+	t.Helper()
+	cacheMu.Lock()
+	cacheTTL[key] = ttl
+	cacheMu.Unlock()
+	t.Cleanup(func() {
+		InvalidateCache(key)
+		cacheMu.Lock()
+		delete(cacheTTL, key)
+		cacheMu.Unlock()
+	})
+}
+
+// age 把缓存的抓取时间往前拨 d
+func age(key string, d time.Duration) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	if e := entries[key]; e != nil {
+		e.fetchedAt = e.fetchedAt.Add(-d)
+	}
+}
+
+func TestCachedFreshHitAndExpiry(t *testing.T) {
+	const key = "test_ttl"
+	useCacheKey(t, key, time.Minute)
+	f := &fakeFetcher{val: "v1"}
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		before    func()
+		want      string
+		wantCalls int32
+	}{
+		{"首次抓取", func() {}, "v1", 1},
+		{"TTL 内命中缓存", func() { f.set("v2", nil) }, "v1", 1},
+		{"过期后重新抓取", func() { age(key, time.Minute) }, "v2", 2},
+		{"刚抓过又命中", func() { f.set("v3", nil) }, "v2", 2},
+		{"InvalidateCache 后重新抓取", func() { InvalidateCache(key) }, "v3", 3},
+	}
+	for _, tt := range tests {
+		tt.before()
+		got, err := cached(ctx, key, f.fetch)
+		if err != nil || got != tt.want || f.calls.Load() != tt.wantCalls {
+			t.Errorf("%s: got %q, %v after %d calls, want %q after %d", tt.name, got, err, f.calls.Load(), tt.want, tt.wantCalls)
+		}
+	}
+}
+
+func TestCachedZeroTTL(t *testing.T) {
+	const key = "test_nocache"
+	useCacheKey(t, key, 0)
+	f := &fakeFetcher{val: "v"}
+	for i := 0; i < 3; i++ {
+		if _, err := cached(context.Background(), key, f.fetch); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := f.calls.Load(); n != 3 {
+		t.Errorf("TTL 0: %d fetches, want 3", n)
+	}
+}
+
+func TestCachedSingleflight(t *testing.T) {
+	const key = "test_flight"
+	useCacheKey(t, key, time.Minute)
+	release := make(chan struct{})
+	var calls atomic.Int32
+	fetch := func(context.Context) (string, error) {
+		calls.Add(1)
+		<-release
+		return "shared", nil
+	}
+
+	const n = 10
+	var wg sync.WaitGroup
+	results := make([]string, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = cached(context.Background(), key, fetch)
+		}(i)
+	}
+	// 等第一个调用方发起抓取后再放行，其余调用方要么在等同一次抓取，要么之后命中缓存
+	for deadline := time.Now().Add(time.Second); calls.Load() == 0 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if c := calls.Load(); c != 1 {
+		t.Errorf("%d concurrent callers caused %d fetches, want 1", n, c)
+	}
+	for i, r := range results {
+		if r != "shared" {
+			t.Errorf("caller %d got %q", i, r)
+		}
+	}
+}
+
+func TestCachedCallerCancel(t *testing.T) {
+	const key = "test_cancel"
+	useCacheKey(t, key, time.Minute)
+	release := make(chan struct{})
+	fetch := func(context.Context) (string, error) {
+		<-release
+		return "late", nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := cached(ctx, key, fetch); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled caller error = %v", err)
+	}
+	// 调用方撤回不影响抓取本身，完成后照常写入缓存
+	close(release)
+	got, err := cached(context.Background(), key, func(context.Context) (string, error) { return "refetched", nil })
+	if err != nil || got != "late" {
+		t.Errorf("after cancel: got %q, %v, want the shared fetch result", got, err)
+	}
+}
+
+func TestCachedStaleFallback(t *testing.T) {
+	const key = "test_stale"
+	useCacheKey(t, key, time.Minute)
+	f := &fakeFetcher{val: "old"}
+	ctx := context.Background()
+	if _, err := cached(ctx, key, f.fetch); err != nil {
+		t.Fatal(err)
+	}
+	upstream := errors.New("upstream down")
+	f.set("", upstream)
+
+	tests := []struct {
+		name    string
+		age     time.Duration
+		want    string
+		wantErr error
+	}{
+		{"过期但未超过 maxStale 时用旧数据", time.Hour, "old", nil},
+		{"超过 maxStale 时返回错误", maxStale, "", upstream},
+	}
+	for _, tt := range tests {
+		age(key, tt.age)
+		got, err := cached(ctx, key, f.fetch)
+		if got != tt.want || !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: got %q, %v, want %q, %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+
+	// 抓取失败不覆盖旧数据，恢复后照常更新
+	f.set("new", nil)
+	if got, _ := cached(ctx, key, f.fetch); got != "new" {
+		t.Errorf("after recovery got %q, want new", got)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	prog, ok := LookupProgramme("hy")
+	if !ok {
+		t.Fatal("programme hy not registered")
+	}
+	base := prog.Base
+	t.Cleanup(func() { InvalidateCache(base) })
+
+	put := func(age time.Duration) {
+		cacheMu.Lock()
+		entries[base] = &cacheEntry{val: "x", fetchedAt: time.Now().Add(-age)}
+		cacheMu.Unlock()
+	}
+	has := func() bool {
+		cacheMu.Lock()
+		defer cacheMu.Unlock()
+		return entries[base] != nil
+	}
+
+	tests := []struct {
+		name string
+		key  string
+		age  time.Duration
+		kept bool
+	}{
+		{"缓存太新时保留", base, time.Second, true},
+		{"超过 minAge 时丢弃", base, time.Minute, false},
+		{"优惠方案刷新其基础来源", "hy", time.Minute, false},
+		{"优惠方案 key 不区分大小写", " HY ", time.Minute, false},
+		{"优惠方案也受 minAge 限制", "hy", time.Second, true},
+	}
+	for _, tt := range tests {
+		put(tt.age)
+		Refresh(tt.key, 15*time.Second)
+		if has() != tt.kept {
+			t.Errorf("%s: kept = %v, want %v", tt.name, has(), tt.kept)
+		}
+	}
+}
+
+func TestConfigureCache(t *testing.T) {
+	t.Cleanup(func() {
+		cacheMu.Lock()
+		delete(cacheTTL, "test_cfg_a")
+		delete(cacheTTL, "test_cfg_b")
+		cacheMu.Unlock()
+	})
+	if err := ConfigureCache(" test_cfg_a=45s, TEST_CFG_B=0 ,"); err != nil {
+		t.Fatal(err)
+	}
+	if d := CacheTTL("test_cfg_a"); d != 45*time.Second {
+		t.Errorf("test_cfg_a TTL = %s", d)
+	}
+	if d := CacheTTL("test_cfg_b"); d != 0 {
+		t.Errorf("test_cfg_b TTL = %s", d)
+	}
+	for _, spec := range []string{"cmb", "cmb=soon", "cmb=-1s"} {
+		if err := ConfigureCache(spec); err == nil {
+			t.Errorf("ConfigureCache(%q) succeeded", spec)
+		}
+	}
+}
@@ -7,10 +7,13 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
 	"golang.org/x/net/html/charset"
 	"golang.org/x/text/transform"
+
+	"aki.telegram.bot.fxrate/currency"
 )
 
 const CGCURL = "https://www.cgbchina.com.cn/searchExchangePrice.gsp?internal_time=14"
@@ -27,41 +30,65 @@ type CGBRate struct {
 	ReleaseTime string  // 发布时间
 }
 
+// CGBSnapshot 一次抓取得到的整张牌价表
+type CGBSnapshot struct {
+	Rates       []CGBRate
+	ReleaseTime string    // 页面发布时间
+	FetchedAt   time.Time // 抓取时间
+}
+
 func GetCGBRate(ctx context.Context, query string) (*CGBRate, bool, error) {
 	if strings.TrimSpace(query) == "" {
 		return nil, false, nil
 	}
 
-	html, err := fetchCGBHTML(ctx)
+	snap, err := GetCGBSnapshot(ctx)
 	if err != nil {
 		return nil, false, err
 	}
+	rate, found := snap.Find(query)
+	return rate, found, nil
+}
+
+// Find 在快照中查找单币种
+func (s *CGBSnapshot) Find(query string) (*CGBRate, bool) {
+	return findRow(s.Rates, query, func(r *CGBRate) string { return r.Symbol })
+}
+
+// GetCGBSnapshot 获取整张牌价表（经缓存，返回值只读）
+func GetCGBSnapshot(ctx context.Context) (*CGBSnapshot, error) {
+	return cached(ctx, "cgb", fetchCGBSnapshot)
+}
+
+func fetchCGBSnapshot(ctx context.Context) (*CGBSnapshot, error) {
+	html, err := fetchCGBHTML(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(html))
 	if err != nil {
-		return nil, false, err
+		return nil, err
 	}
 
 	releaseTime, err := parseCGBReleaseTime(doc)
 	if err != nil {
-		return nil, false, err
+		return nil, err
 	}
-	targetCN := normalizeQueryCGB(query)
-	targetCode := normalizeCodeCGB(query)
 
 	table := doc.Find("table.ratetable").First()
 	if table.Length() == 0 {
-		return nil, false, fmt.Errorf("CGB: 未找到牌价表")
+		return nil, fmt.Errorf("CGB: 未找到牌价表")
 	}
 
-	var out *CGBRate
-	table.Find("tr").EachWithBreak(func(i int, tr *goquery.Selection) bool {
+	var rates []CGBRate
+	table.Find("tr").Each(func(i int, tr *goquery.Selection) {
 		if i == 0 {
-			return true
+			return
 		}
 		tds := tr.Find("td")
 		if tds.Length() < 8 {
-			return true
+			return
 		}
 
 		nameRaw := strings.TrimSpace(tds.Eq(0).Text()) // 美元/人民币
@@ -74,32 +101,28 @@ func GetCGBRate(ctx context.Context, query string) (*CGBRate, bool, error) {
 		sellCash := strings.TrimSpace(tds.Eq(7).Text())
 
 		nameLeft := beforeSlash(nameRaw)
-		codeLeft := beforeSlash(codeRaw)
-
-		if !matchCGB(nameLeft, codeLeft, targetCN, targetCode) {
-			return true
+		codeLeft := strings.ToUpper(beforeSlash(codeRaw))
+		if codeLeft == "" {
+			codeLeft = currency.Code(nameLeft)
 		}
 
-		unit := parseUnit(unitRaw)
-
-		out = &CGBRate{
+		rates = append(rates, CGBRate{
 			Name:        nz(nameLeft, "-"),
-			Symbol:      nz(strings.ToUpper(codeLeft), "-"),
-			Unit:        unit,
+			Symbol:      nz(codeLeft, "-"),
+			Unit:        parseUnit(unitRaw),
 			MiddleRate:  nz(middle, "-"),
 			BuySpot:     nz(buySpot, "-"),
 			BuyCash:     nz(buyCash, "-"),
 			SellSpot:    nz(sellSpot, "-"),
 			SellCash:    nz(sellCash, "-"),
 			ReleaseTime: nz(releaseTime, "-"),
-		}
-		return false
+		})
 	})
-
-	if out == nil {
-		return nil, false, nil
-	}
-	return out, true, nil
+	return &CGBSnapshot{
+		Rates:       rates,
+		ReleaseTime: nz(releaseTime, "-"),
+		FetchedAt:   time.Now(),
+	}, nil
 }
 
 // ---- HTTP ----
@@ -180,68 +203,37 @@ func parseUnit(s string) float64 {
 	return 100
 }
 
-func normalizeQueryCGB(q string) string {
-	q = strings.TrimSpace(q)
-	if q == "" {
-		return q
-	}
-	lq := strings.ToLower(q)
-	lq = strings.ReplaceAll(lq, "_", "")
-	lq = strings.ReplaceAll(lq, "-", "")
-	lq = strings.ReplaceAll(lq, "/", "")
-	if cn, ok := codeToCN[lq]; ok {
-		// 允许用代码匹配到中文名
-		return cn
-	}
-	return q
-}
+// ---- Provider ----
 
-func matchCGB(nameLeft, codeLeft, targetCN, targetCode string) bool {
-	nameCN := unifyCN(strings.TrimSpace(nameLeft))
-	code := strings.TrimSpace(codeLeft)
-	tCN := unifyCN(strings.TrimSpace(targetCN))
-	tCode := strings.ToUpper(strings.TrimSpace(targetCode))
+func init() { Register(cgbProvider{}) }
 
-	if tCode != "" && strings.EqualFold(code, tCode) {
-		return true
-	}
-	if tCN != "" && (strings.Contains(nameCN, tCN) || strings.Contains(tCN, nameCN)) {
-		return true
-	}
-	return false
-}
+type cgbProvider struct{}
 
-// 返回币种代码
-func normalizeCodeCGB(q string) string {
-	q = strings.TrimSpace(q)
-	if q == "" {
-		return ""
-	}
-	lq := strings.ToLower(q)
-	lq = strings.ReplaceAll(lq, "_", "")
-	lq = strings.ReplaceAll(lq, "-", "")
-	lq = strings.ReplaceAll(lq, "/", "")
-	// 仅保留字母
-	var b strings.Builder
-	for _, r := range lq {
-		if r >= 'a' && r <= 'z' {
-			b.WriteRune(r)
-		}
-	}
-	s := b.String()
-	if len(s) == 3 { // 常见三字母代码
-		return strings.ToUpper(s)
-	}
-	return ""
+func (cgbProvider) Key() string  { return "cgb" }
+func (cgbProvider) Name() string { return "广发银行" }
+
+func (cgbProvider) Currencies() []string {
+	return []string{"USD", "HKD", "EUR", "JPY", "GBP", "AUD", "CAD", "CHF", "SGD", "NZD"}
 }
 
-func unifyCN(s string) string {
-	s = strings.TrimSpace(s)
-	if s == "" {
-		return s
+func (cgbProvider) Snapshot(ctx context.Context) (*Snapshot, error) {
+	snap, err := GetCGBSnapshot(ctx)
+	if err != nil {
+		return nil, err
 	}
-	s = strings.ReplaceAll(s, "港币", "港元")
-	s = strings.ReplaceAll(s, "澳门币", "澳门元")
-	s = strings.ReplaceAll(s, "台币", "新台币")
-	return s
-}
\ No newline at end of file
+	out := make([]Quote, 0, len(snap.Rates))
+	for _, r := range snap.Rates {
+		out = append(out, Quote{
+			Code:        normCode(r.Symbol),
+			Name:        r.Name,
+			Unit:        int64(r.Unit),
+			BuySpot:     parsePrice(r.BuySpot),
+			BuyCash:     parsePrice(r.BuyCash),
+			SellSpot:    parsePrice(r.SellSpot),
+			SellCash:    parsePrice(r.SellCash),
+			Middle:      parsePrice(r.MiddleRate),
+			ReleaseTime: parseReleaseTime(r.ReleaseTime),
+		})
+	}
+	return newSnapshot("cgb", out, snap.ReleaseTime, snap.FetchedAt), nil
+}
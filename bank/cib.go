@@ -7,11 +7,13 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/shopspring/decimal"
+
+	"aki.telegram.bot.fxrate/currency"
 )
 
 const (
@@ -30,42 +32,65 @@ type CIBRate struct {
 	ReleaseTime string // 汇率发布时间
 }
 
+// CIBSnapshot 一次抓取得到的整张牌价表
+type CIBSnapshot struct {
+	Rates       []CIBRate
+	ReleaseTime string    // 页面发布时间
+	FetchedAt   time.Time // 抓取时间
+}
+
 // GetCIBRate 通过“代码/中文名/模糊匹配”获取单币种牌价
 // 返回：rate，found，error
 func GetCIBRate(ctx context.Context, query string) (*CIBRate, bool, error) {
+	snap, err := GetCIBSnapshot(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	rate, found := snap.Find(query)
+	return rate, found, nil
+}
+
+// Find 在快照中查找单币种
+func (s *CIBSnapshot) Find(query string) (*CIBRate, bool) {
+	return findRow(s.Rates, query, func(r *CIBRate) string { return r.Symbol })
+}
+
+// GetCIBSnapshot 获取整张牌价表（经缓存，返回值只读）
+func GetCIBSnapshot(ctx context.Context) (*CIBSnapshot, error) {
+	return cached(ctx, "cib", fetchCIBSnapshot)
+}
+
+func fetchCIBSnapshot(ctx context.Context) (*CIBSnapshot, error) {
 	// 1) 获取 HTML 以拿 Cookie 和更新时间
 	html, cookie, err := fetchCIBHTML(ctx)
 	if err != nil {
-		return nil, false, err
+		return nil, err
 	}
 	releaseTime := parseCIBReleaseTime(html)
 
 	// 2) 请求 JSON 列表
 	rows, err := fetchCIBRows(ctx, cookie)
 	if err != nil {
-		return nil, false, err
+		return nil, err
 	}
 
-	target := normalizeQueryCIB(query)
-
-	// 3) 遍历匹配
+	rates := make([]CIBRate, 0, len(rows))
 	for _, cells := range rows {
 		if len(cells) < 7 {
 			continue
 		}
 
-		// 代码与名称直接来自接口
-		code := strings.TrimSpace(toStr(cells[1]))
+		// 代码与名称直接来自接口，代码缺失时按中文名识别
+		code := strings.ToUpper(strings.TrimSpace(toStr(cells[1])))
 		name := strings.TrimSpace(toStr(cells[0]))
 		if name == "" {
 			name = code
 		}
-
-		if !matchCIBCurrency(name, code, target) {
-			continue
+		if code == "" {
+			code = currency.Code(name)
 		}
 
-		rate := &CIBRate{
+		rates = append(rates, CIBRate{
 			Name:        name,
 			Symbol:      code,
 			BuySpot:     nz(strings.TrimSpace(toStr(cells[3])), "-"),
@@ -73,11 +98,13 @@ func GetCIBRate(ctx context.Context, query string) (*CIBRate, bool, error) {
 			BuyCash:     nz(strings.TrimSpace(toStr(cells[5])), "-"),
 			SellCash:    nz(strings.TrimSpace(toStr(cells[6])), "-"),
 			ReleaseTime: nz(releaseTime, "-"),
-		}
-		return rate, true, nil
+		})
 	}
-
-	return nil, false, nil
+	return &CIBSnapshot{
+		Rates:       rates,
+		ReleaseTime: nz(releaseTime, "-"),
+		FetchedAt:   time.Now(),
+	}, nil
 }
 
 // 使用默认传输层的简单 HTTP 客户端（保留超时）
@@ -232,85 +259,49 @@ func toStr(v any) string {
 	}
 }
 
-func normalizeQueryCIB(q string) string {
-	q = strings.TrimSpace(q)
-	if q == "" {
-		return q
-	}
-	lq := strings.ToLower(q)
-	lq = strings.ReplaceAll(lq, "_", "")
-	lq = strings.ReplaceAll(lq, "-", "")
-	lq = strings.ReplaceAll(lq, "/", "")
-	return lq
-}
+// ---- Provider ----
 
-func matchCIBCurrency(name, code, target string) bool {
-	name = strings.TrimSpace(name)
-	code = strings.TrimSpace(code)
-	target = strings.TrimSpace(target)
-	if name == "" || target == "" {
-		return false
-	}
-	// 直接相等（中英文）
-	if strings.EqualFold(name, target) || strings.EqualFold(code, target) {
-		return true
-	}
-	// 模糊包含
-	return strings.Contains(name, target) ||
-		strings.Contains(target, name) ||
-		strings.Contains(strings.ToLower(code), strings.ToLower(target)) ||
-		strings.Contains(strings.ToLower(target), strings.ToLower(code))
+// cibLifeProgramme 兴业寰宇人生借记卡：现汇买卖价与中间价的差值打 5 折，现钞不优惠
+var cibLifeProgramme = Programme{
+	Key:  "hy",
+	Name: "寰宇人生",
+	Base: "cib",
+	Rule: Rule{SpreadOff: decimal.NewFromFloat(0.5), SpotOnly: true},
 }
 
-// ------ 寰宇人生借记卡 ------
-
-type CIBLifeRate struct {
-	Name        string // 币种中文名
-	Symbol      string // 币种符号
-	BuySpot     string // 现汇买入价
-	BuyCash     string // 现钞买入价
-	SellSpot    string // 现汇卖出价
-	SellCash    string // 现钞卖出价
-	ReleaseTime string // 汇率发布时间
-}
-
-func GetCIBLifeRate(ctx context.Context, query string) (*CIBLifeRate, bool, error) {
-	cibRate, found, err := GetCIBRate(ctx, query)
-	if err != nil || !found || cibRate == nil {
-		return nil, false, err
+func init() {
+	Register(cibProvider{})
+	if err := RegisterProgramme(cibLifeProgramme); err != nil {
+		panic(err)
 	}
+}
 
-	// 解析价格
-	buySpot, _ := strconv.ParseFloat(strings.ReplaceAll(cibRate.BuySpot, ",", ""), 64)
-	sellSpot, _ := strconv.ParseFloat(strings.ReplaceAll(cibRate.SellSpot, ",", ""), 64)
-
-	// 计算中间价
-	mid := (buySpot + sellSpot) / 2
+var cibCurrencies = []string{"USD", "EUR", "HKD", "JPY", "GBP", "AUD", "CAD", "CHF", "SGD", "NZD", "DKK", "NOK", "SEK"}
 
-	// 购汇/结汇价与中间价的差值
-	buyDiff := mid - buySpot
-	sellDiff := sellSpot - mid
+type cibProvider struct{}
 
-	// 5折优惠
-	buySpotLife := mid - buyDiff*0.5
-	sellSpotLife := mid + sellDiff*0.5
+func (cibProvider) Key() string          { return "cib" }
+func (cibProvider) Name() string         { return "兴业银行" }
+func (cibProvider) Currencies() []string { return append([]string(nil), cibCurrencies...) }
 
-	// 保留小数点后4位
-	format := func(f float64) string {
-		if f == 0 {
-			return "-"
-		}
-		return fmt.Sprintf("%.4f", f)
+func (cibProvider) Snapshot(ctx context.Context) (*Snapshot, error) {
+	snap, err := GetCIBSnapshot(ctx)
+	if err != nil {
+		return nil, err
 	}
-
-	rate := &CIBLifeRate{
-		Name:        cibRate.Name,
-		Symbol:      cibRate.Symbol,
-		BuySpot:     format(buySpotLife),
-		BuyCash:     cibRate.BuyCash,
-		SellSpot:    format(sellSpotLife),
-		SellCash:    cibRate.SellCash,
-		ReleaseTime: cibRate.ReleaseTime,
+	out := make([]Quote, 0, len(snap.Rates))
+	for _, r := range snap.Rates {
+		out = append(out, Quote{
+			Code:        normCode(r.Symbol),
+			Name:        r.Name,
+			Unit:        100,
+			BuySpot:     parsePrice(r.BuySpot),
+			BuyCash:     parsePrice(r.BuyCash),
+			SellSpot:    parsePrice(r.SellSpot),
+			SellCash:    parsePrice(r.SellCash),
+			Middle:      decimal.NullDecimal{},
+			ReleaseTime: parseReleaseTime(r.ReleaseTime),
+		})
 	}
-	return rate, true, nil
+	return newSnapshot("cib", out, snap.ReleaseTime, snap.FetchedAt), nil
 }
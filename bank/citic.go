@@ -8,6 +8,10 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/shopspring/decimal"
+
+	"aki.telegram.bot.fxrate/currency"
 )
 
 const citicURL = "https://etrade.citicbank.com/portalweb/cms/getForeignExchRate.htm"
@@ -20,41 +24,65 @@ type CITICRate struct {
 	ReleaseTime string // 发布时间
 }
 
+// CITICSnapshot 一次抓取得到的整张牌价表
+type CITICSnapshot struct {
+	Rates       []CITICRate
+	ReleaseTime string    // 发布时间（取各行最新）
+	FetchedAt   time.Time // 抓取时间
+}
+
 func GetCITICRate(ctx context.Context, query string) (*CITICRate, bool, error) {
-	rows, err := fetchCITICRows(ctx)
+	target := strings.TrimSpace(query)
+	if target == "" {
+		return nil, false, nil
+	}
+
+	snap, err := GetCITICSnapshot(ctx)
 	if err != nil {
 		return nil, false, err
 	}
+	rate, found := snap.Find(target)
+	return rate, found, nil
+}
 
-	target := strings.TrimSpace(query)
-	if target == "" {
-		return nil, false, nil
+// Find 在快照中查找单币种
+func (s *CITICSnapshot) Find(query string) (*CITICRate, bool) {
+	return findRow(s.Rates, query, func(r *CITICRate) string { return r.Symbol })
+}
+
+// GetCITICSnapshot 获取整张牌价表（经缓存，返回值只读）
+func GetCITICSnapshot(ctx context.Context) (*CITICSnapshot, error) {
+	return cached(ctx, "citic", fetchCITICSnapshot)
+}
+
+func fetchCITICSnapshot(ctx context.Context) (*CITICSnapshot, error) {
+	rows, err := fetchCITICRows(ctx)
+	if err != nil {
+		return nil, err
 	}
-	lt := strings.ToLower(target)
-	lt = strings.ReplaceAll(lt, "_", "")
-	lt = strings.ReplaceAll(lt, "-", "")
-	lt = strings.ReplaceAll(lt, "/", "")
 
+	rates := make([]CITICRate, 0, len(rows))
 	for _, r := range rows {
 		name := strings.TrimSpace(r.CurName)
-
-		code3 := citicNameToCode(name)
-		if !(strings.Contains(name, target) || strings.EqualFold(code3, lt)) {
-			continue
-		}
-
 		ts := composeCITICTime(r.QuotePriceDate, r.QuotePriceTime)
-		rate := &CITICRate{
+		rates = append(rates, CITICRate{
 			Name:        nz(name, "-"),
-			Symbol:      nz(code3, "-"),
+			Symbol:      nz(currency.Code(name), "-"),
 			BuySpot:     nz(strings.TrimSpace(r.CstexcBuyPrice), "-"),
 			SellSpot:    nz(strings.TrimSpace(r.CstexcSellPrice), "-"),
 			ReleaseTime: nz(ts, "-"),
-		}
-		return rate, true, nil
+		})
 	}
 
-	return nil, false, nil
+	releaseTimes := make([]string, 0, len(rates))
+	for _, r := range rates {
+		releaseTimes = append(releaseTimes, r.ReleaseTime)
+	}
+	return &CITICSnapshot{
+		Rates:       rates,
+		ReleaseTime: latestTime(releaseTimes...),
+		FetchedAt:   time.Now(),
+	}, nil
 }
 
 type citicResponse struct {
@@ -129,24 +157,37 @@ func composeCITICTime(d, t string) string {
 	return s
 }
 
-func citicNameToCode(cn string) string {
-	name := strings.TrimSpace(cn)
-	if name == "" {
-		return ""
-	}
-	// 查找现有的
-	for code, n := range codeToCN {
-		if strings.EqualFold(n, name) {
-			return strings.ToUpper(code)
-		}
+// ---- Provider ----
+
+func init() { Register(citicProvider{}) }
+
+type citicProvider struct{}
+
+func (citicProvider) Key() string  { return "citic" }
+func (citicProvider) Name() string { return "中信银行" }
+
+func (citicProvider) Currencies() []string {
+	return []string{"USD", "HKD", "EUR", "JPY", "GBP", "AUD", "CAD", "CHF", "SGD", "NZD", "DKK", "NOK", "SEK", "KRW", "KZT"}
+}
+
+func (citicProvider) Snapshot(ctx context.Context) (*Snapshot, error) {
+	snap, err := GetCITICSnapshot(ctx)
+	if err != nil {
+		return nil, err
 	}
-	// 别的名字或者说没有的
-	switch name {
-	case "韩元":
-		return "KRW"
-	case "坚戈":
-		return "KZT"
-	default:
-		return ""
+	out := make([]Quote, 0, len(snap.Rates))
+	for _, r := range snap.Rates {
+		out = append(out, Quote{
+			Code:        normCode(r.Symbol),
+			Name:        r.Name,
+			Unit:        100,
+			BuySpot:     parsePrice(r.BuySpot),
+			BuyCash:     decimal.NullDecimal{},
+			SellSpot:    parsePrice(r.SellSpot),
+			SellCash:    decimal.NullDecimal{},
+			Middle:      decimal.NullDecimal{},
+			ReleaseTime: parseReleaseTime(r.ReleaseTime),
+		})
 	}
-}
\ No newline at end of file
+	return newSnapshot("citic", out, snap.ReleaseTime, snap.FetchedAt), nil
+}
@@ -7,6 +7,9 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
+
+	"aki.telegram.bot.fxrate/currency"
 )
 
 const cmbURL = "https://fx.cmbchina.com/api/v1/fx/rate"
@@ -22,29 +25,51 @@ type CMBRate struct {
 	ReleaseTime string // 汇率发布时间
 }
 
+// CMBSnapshot 一次抓取得到的整张牌价表
+type CMBSnapshot struct {
+	Rates       []CMBRate
+	ReleaseTime string    // 发布时间（取各行最新）
+	FetchedAt   time.Time // 抓取时间
+}
+
 // GetCMBRate 通过“代码/中文名/模糊匹配”获取单币种牌价
 // 返回：rate，found，error
 func GetCMBRate(ctx context.Context, query string) (*CMBRate, bool, error) {
-	rows, err := fetchCMBRows(ctx)
+	snap, err := GetCMBSnapshot(ctx)
 	if err != nil {
 		return nil, false, err
 	}
+	rate, found := snap.Find(query)
+	return rate, found, nil
+}
+
+// Find 在快照中查找单币种
+func (s *CMBSnapshot) Find(query string) (*CMBRate, bool) {
+	return findRow(s.Rates, query, func(r *CMBRate) string { return r.Symbol })
+}
 
-	target := strings.TrimSpace(query)
-	lt := strings.ToLower(target)
+// GetCMBSnapshot 获取整张牌价表（经缓存，返回值只读）
+func GetCMBSnapshot(ctx context.Context) (*CMBSnapshot, error) {
+	return cached(ctx, "cmb", fetchCMBSnapshot)
+}
 
+func fetchCMBSnapshot(ctx context.Context) (*CMBSnapshot, error) {
+	rows, err := fetchCMBRows(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rates := make([]CMBRate, 0, len(rows))
 	for _, r := range rows {
 		name := strings.TrimSpace(r.CcyNbr)
 		eng := strings.TrimSpace(r.CcyNbrEng)
 		symbol := extractCMBSymbol(eng)
-
-		if !matchCMBCurrency(name, eng, symbol, lt) {
-			continue
+		if symbol == "" {
+			symbol = currency.Code(name)
 		}
-
 		ts := composeCMBTime(r.RatDat, r.RatTim)
 
-		rate := &CMBRate{
+		rates = append(rates, CMBRate{
 			Name:        nz(name, "-"),
 			Symbol:      nz(symbol, "-"),
 			BuySpot:     nz(strings.TrimSpace(r.RthBid), "-"),
@@ -53,11 +78,18 @@ func GetCMBRate(ctx context.Context, query string) (*CMBRate, bool, error) {
 			SellCash:    nz(strings.TrimSpace(r.RtcOfr), "-"),
 			BankRate:    nz(strings.TrimSpace(r.RtbBid), "-"),
 			ReleaseTime: nz(ts, "-"),
-		}
-		return rate, true, nil
+		})
 	}
 
-	return nil, false, nil
+	releaseTimes := make([]string, 0, len(rates))
+	for _, r := range rates {
+		releaseTimes = append(releaseTimes, r.ReleaseTime)
+	}
+	return &CMBSnapshot{
+		Rates:       rates,
+		ReleaseTime: latestTime(releaseTimes...),
+		FetchedAt:   time.Now(),
+	}, nil
 }
 
 type cmbResponse struct {
@@ -158,23 +190,37 @@ func composeCMBTime(ratDat, ratTim string) string {
 	return s
 }
 
-// 招行更新汇率的频率好快啊...
+// ---- Provider ----
 
-func matchCMBCurrency(nameCN, eng, code, ltTarget string) bool {
-	if ltTarget == "" {
-		return false
-	}
-	// 中文名直接包含
-	if strings.Contains(nameCN, ltTarget) {
-		return true
-	}
-	// 英文整串包含（不区分大小写）
-	if strings.Contains(strings.ToLower(eng), ltTarget) {
-		return true
-	}
-	// 代码完全匹配
-	if strings.EqualFold(code, ltTarget) {
-		return true
+func init() { Register(cmbProvider{}) }
+
+type cmbProvider struct{}
+
+func (cmbProvider) Key() string  { return "cmb" }
+func (cmbProvider) Name() string { return "招商银行" }
+
+func (cmbProvider) Currencies() []string {
+	return []string{"HKD", "AUD", "USD", "EUR", "CAD", "NZD", "GBP", "JPY", "SGD", "CHF", "THB"}
+}
+
+func (cmbProvider) Snapshot(ctx context.Context) (*Snapshot, error) {
+	snap, err := GetCMBSnapshot(ctx)
+	if err != nil {
+		return nil, err
 	}
-	return false
+	out := make([]Quote, 0, len(snap.Rates))
+	for _, r := range snap.Rates {
+		out = append(out, Quote{
+			Code:        normCode(r.Symbol),
+			Name:        r.Name,
+			Unit:        100,
+			BuySpot:     parsePrice(r.BuySpot),
+			BuyCash:     parsePrice(r.BuyCash),
+			SellSpot:    parsePrice(r.SellSpot),
+			SellCash:    parsePrice(r.SellCash),
+			Middle:      parsePrice(r.BankRate),
+			ReleaseTime: parseReleaseTime(r.ReleaseTime),
+		})
+	}
+	return newSnapshot("cmb", out, snap.ReleaseTime, snap.FetchedAt), nil
 }
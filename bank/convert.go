@@ -0,0 +1,176 @@
+package bank
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"aki.telegram.bot.fxrate/currency"
+	"aki.telegram.bot.fxrate/money"
+)
+
+// Method 现汇 / 现钞
+type Method int
+
+const (
+	Spot Method = iota // 现汇（账户内的外汇）
+	Cash               // 现钞
+)
+
+// Label 中文名
+func (m Method) Label() string {
+	if m == Cash {
+		return "现钞"
+	}
+	return "现汇"
+}
+
+// other 另一种方式，用于回落
+func (m Method) other() Method {
+	if m == Cash {
+		return Spot
+	}
+	return Cash
+}
+
+// Side 使用哪一侧牌价
+type Side int
+
+const (
+	AutoSide Side = iota // 按方向自动选择：结汇用买入价，购汇用卖出价
+	BuySide              // 强制用买入价
+	SellSide             // 强制用卖出价
+	MidSide              // 中间价/折算价，不区分现汇现钞
+)
+
+var (
+	// ErrNoPrice 该币种在对应方向上没有可用的现汇/现钞牌价
+	ErrNoPrice = errors.New("bank: no usable price for this direction")
+	// ErrUnsupportedPair 换算的两边必须恰好一边是人民币，另一边是该牌价的币种
+	ErrUnsupportedPair = errors.New("bank: unsupported currency pair")
+)
+
+// SideField 按方向与方式取牌价项：
+// 外币 -> CNY 是结汇，银行向客户买入外币，用买入价；
+// CNY -> 外币 是购汇，银行向客户卖出外币，用卖出价。
+func SideField(toCNY bool, m Method) Field {
+	switch {
+	case toCNY && m == Cash:
+		return BuyCash
+	case toCNY:
+		return BuySpot
+	case m == Cash:
+		return SellCash
+	default:
+		return SellSpot
+	}
+}
+
+// pick 按 side 与方向、方式确定牌价项
+func (s Side) pick(toCNY bool, m Method) Field {
+	switch s {
+	case BuySide:
+		return SideField(true, m)
+	case SellSide:
+		return SideField(false, m)
+	case MidSide:
+		return Middle
+	default:
+		return SideField(toCNY, m)
+	}
+}
+
+// Conversion 一次换算的结果
+type Conversion struct {
+	From, To string          // ISO 代码
+	Amount   decimal.Decimal // 输入金额（From）
+	Result   decimal.Decimal // 换算结果（To），已按 To 的最小单位舍入
+	Field    Field           // 实际使用的牌价项
+	Wanted   Field           // 按参数本应使用的牌价项，Fallback 时与 Field 不同
+	Rate     decimal.Decimal // 实际使用的牌价，按 Unit 计
+	Unit     int64           // 牌价基数
+	Fallback bool            // 指定方式缺价，回落到了另一种方式
+	Quote    *Quote
+}
+
+// ToCNY 是否为结汇方向
+func (c *Conversion) ToCNY() bool {
+	return currency.IsCNY(c.To)
+}
+
+// Convert 按 q 的牌价在 CNY 与 q.Code 之间换算 amount。
+// side 为 AutoSide 时由方向决定买入/卖出价，m 决定现汇/现钞；
+// 指定方式缺价时回落到另一种方式并标记 Fallback，中间价没有可回落的项。
+func Convert(q *Quote, from, to string, amount decimal.Decimal, side Side, m Method, mode money.RoundingMode) (*Conversion, error) {
+	from, to = currency.Normalize(from), currency.Normalize(to)
+	var toCNY bool
+	switch {
+	case currency.IsCNY(to) && strings.EqualFold(from, q.Code):
+		toCNY = true
+	case currency.IsCNY(from) && strings.EqualFold(to, q.Code):
+		toCNY = false
+	default:
+		return nil, ErrUnsupportedPair
+	}
+
+	c := &Conversion{From: from, To: to, Amount: amount, Unit: q.Unit, Quote: q}
+	c.Wanted = side.pick(toCNY, m)
+	c.Field = c.Wanted
+	v := q.Get(c.Field)
+	if !v.Valid && side != MidSide {
+		c.Field = side.pick(toCNY, m.other())
+		c.Fallback = true
+		v = q.Get(c.Field)
+	}
+	if !v.Valid {
+		return nil, ErrNoPrice
+	}
+	c.Rate = v.Decimal
+
+	if toCNY {
+		c.Result = money.FXToCNY(amount, c.Rate, c.Unit, mode)
+	} else {
+		c.Result = money.CNYToFX(amount, c.Rate, c.Unit, to, mode)
+	}
+	return c, nil
+}
+
+// crossDigits 交叉汇率展示的小数位
+const crossDigits = 6
+
+// Cross 经人民币中转的外币间换算
+type Cross struct {
+	Source *Conversion     // 第一腿：源外币 -> CNY
+	Target *Conversion     // 第二腿：CNY -> 目标外币
+	Rate   decimal.Decimal // 实际交叉汇率：1 源外币 = Rate 目标外币
+}
+
+// Result 最终得到的目标外币金额
+func (x *Cross) Result() decimal.Decimal {
+	return x.Target.Result
+}
+
+// ConvertCross 用 src、dst 两行牌价把 amount 从 src.Code 换成 dst.Code：
+// 先按 src 结汇成人民币（买入价），再按 dst 购汇（卖出价）；side、m 的含义同 Convert，两腿共用。
+// 中间的人民币金额按分舍入后再进入第二腿，与银行实际两笔交易一致。
+func ConvertCross(src, dst *Quote, amount decimal.Decimal, side Side, m Method, mode money.RoundingMode) (*Cross, error) {
+	if currency.IsCNY(src.Code) || currency.IsCNY(dst.Code) || strings.EqualFold(src.Code, dst.Code) {
+		return nil, ErrUnsupportedPair
+	}
+	leg1, err := Convert(src, src.Code, "CNY", amount, side, m, mode)
+	if err != nil {
+		return nil, err
+	}
+	leg2, err := Convert(dst, "CNY", dst.Code, leg1.Result, side, m, mode)
+	if err != nil {
+		return nil, err
+	}
+	perSrc := src.Per(leg1.Field, 1).Decimal
+	perDst := dst.Per(leg2.Field, 1).Decimal
+	return &Cross{
+		Source: leg1,
+		Target: leg2,
+		Rate:   perSrc.DivRound(perDst, crossDigits),
+	}, nil
+}
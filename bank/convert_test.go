@@ -0,0 +1,143 @@
+package bank
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"aki.telegram.bot.fxrate/money"
+)
+
+func price(s string) decimal.NullDecimal {
+	if s == "" {
+		return decimal.NullDecimal{}
+	}
+	return decimal.NewNullDecimal(decimal.RequireFromString(s))
+}
+
+// 美元没有现钞卖出价，用来测回落
+var (
+	testUSD = &Quote{Code: "USD", Unit: 100,
+		BuySpot: price("710.00"), BuyCash: price("704.50"), SellSpot: price("713.00"), Middle: price("711.00")}
+	testJPY = &Quote{Code: "JPY", Unit: 100,
+		BuySpot: price("4.8800"), SellSpot: price("4.9123"), SellCash: price("4.9500")}
+	testHKD = &Quote{Code: "HKD", Unit: 100,
+		BuySpot: price("90.50"), BuyCash: price("89.80"), SellSpot: price("91.00"), SellCash: price("91.00")}
+	testEmpty = &Quote{Code: "EUR", Unit: 100}
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name     string
+		q        *Quote
+		from, to string
+		amount   string
+		side     Side
+		m        Method
+		mode     money.RoundingMode
+		want     string
+		field    Field
+		fallback bool
+	}{
+		{"结汇用现汇买入价", testUSD, "USD", "CNY", "100", AutoSide, Spot, money.HalfUp, "710", BuySpot, false},
+		{"结汇用现钞买入价", testUSD, "usd", "cny", "100", AutoSide, Cash, money.HalfUp, "704.5", BuyCash, false},
+		{"购汇用现汇卖出价", testUSD, "CNY", "USD", "1000", AutoSide, Spot, money.HalfUp, "140.25", SellSpot, false},
+		{"购汇向上舍入", testUSD, "CNY", "USD", "1000", AutoSide, Spot, money.Up, "140.26", SellSpot, false},
+		{"现钞缺价回落现汇", testUSD, "CNY", "USD", "1000", AutoSide, Cash, money.HalfUp, "140.25", SellSpot, true},
+		{"强制买入价购汇", testUSD, "CNY", "USD", "1000", BuySide, Spot, money.HalfUp, "140.85", BuySpot, false},
+		{"强制卖出价结汇", testUSD, "USD", "CNY", "100", SellSide, Spot, money.HalfUp, "713", SellSpot, false},
+		{"中间价", testUSD, "USD", "CNY", "100", MidSide, Cash, money.HalfUp, "711", Middle, false},
+		{"人民币分位四舍五入", testUSD, "USD", "CNY", "0.15", AutoSide, Spot, money.HalfUp, "1.07", BuySpot, false},
+		{"人民币分位银行家舍入", testUSD, "USD", "CNY", "0.15", AutoSide, Spot, money.HalfEven, "1.06", BuySpot, false},
+		{"人民币分位截断", testUSD, "USD", "CNY", "0.15", AutoSide, Spot, money.Down, "1.06", BuySpot, false},
+		{"日元没有小数", testJPY, "CNY", "JPY", "100", AutoSide, Spot, money.HalfUp, "2036", SellSpot, false},
+		{"日元截断", testJPY, "CNY", "JPY", "100", AutoSide, Spot, money.Down, "2035", SellSpot, false},
+		{"日元现钞", testJPY, "CNY", "JPY", "99", AutoSide, Cash, money.HalfUp, "2000", SellCash, false},
+	}
+	for _, tt := range tests {
+		c, err := Convert(tt.q, tt.from, tt.to, decimal.RequireFromString(tt.amount), tt.side, tt.m, tt.mode)
+		if err != nil {
+			t.Errorf("%s: error %v", tt.name, err)
+			continue
+		}
+		if !c.Result.Equal(decimal.RequireFromString(tt.want)) {
+			t.Errorf("%s: Result = %s, want %s", tt.name, c.Result, tt.want)
+		}
+		if c.Field != tt.field || c.Fallback != tt.fallback {
+			t.Errorf("%s: Field = %s (fallback %v), want %s (fallback %v)", tt.name, c.Field.Label(), c.Fallback, tt.field.Label(), tt.fallback)
+		}
+		if !tt.fallback && c.Wanted != c.Field {
+			t.Errorf("%s: Wanted = %s, want %s", tt.name, c.Wanted.Label(), c.Field.Label())
+		}
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		q        *Quote
+		from, to string
+		side     Side
+		want     error
+	}{
+		{"币种与牌价不符", testUSD, "EUR", "CNY", AutoSide, ErrUnsupportedPair},
+		{"两边都不是人民币", testUSD, "USD", "HKD", AutoSide, ErrUnsupportedPair},
+		{"两边都是人民币", testUSD, "CNY", "CNY", AutoSide, ErrUnsupportedPair},
+		{"中间价缺失不回落", testJPY, "JPY", "CNY", MidSide, ErrNoPrice},
+		{"两种方式都缺价", testEmpty, "EUR", "CNY", AutoSide, ErrNoPrice},
+	}
+	for _, tt := range tests {
+		if _, err := Convert(tt.q, tt.from, tt.to, decimal.NewFromInt(100), tt.side, Spot, money.HalfUp); !errors.Is(err, tt.want) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestConvertCross(t *testing.T) {
+	x, err := ConvertCross(testUSD, testHKD, decimal.NewFromInt(100), AutoSide, Spot, money.HalfUp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 100 USD 按 710.00 结汇得 710.00 CNY，再按 91.00 购汇
+	if !x.Source.Result.Equal(decimal.RequireFromString("710")) {
+		t.Errorf("Source.Result = %s, want 710", x.Source.Result)
+	}
+	if !x.Result().Equal(decimal.RequireFromString("780.22")) {
+		t.Errorf("Result = %s, want 780.22", x.Result())
+	}
+	if !x.Rate.Equal(decimal.RequireFromString("7.802198")) {
+		t.Errorf("Rate = %s, want 7.802198", x.Rate)
+	}
+
+	// 美元有现钞买入价，港币现钞齐全：两腿都用现钞
+	x, err = ConvertCross(testUSD, testHKD, decimal.NewFromInt(100), AutoSide, Cash, money.HalfUp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x.Source.Field != BuyCash || x.Target.Field != SellCash || x.Source.Fallback || x.Target.Fallback {
+		t.Errorf("cash legs = %s/%s", x.Source.Field.Label(), x.Target.Field.Label())
+	}
+
+	// 港币换美元：美元缺现钞卖出价，第二腿回落现汇
+	x, err = ConvertCross(testHKD, testUSD, decimal.NewFromInt(1000), AutoSide, Cash, money.HalfUp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x.Target.Field != SellSpot || !x.Target.Fallback {
+		t.Errorf("Target.Field = %s (fallback %v), want 现汇卖出价 fallback", x.Target.Field.Label(), x.Target.Fallback)
+	}
+	// 1000 HKD 按 89.80 结汇得 898.00 CNY，再按 713.00 购汇
+	if !x.Result().Equal(decimal.RequireFromString("125.95")) {
+		t.Errorf("Result = %s, want 125.95", x.Result())
+	}
+
+	for _, pair := range [][2]*Quote{{testUSD, testUSD}, {&Quote{Code: "CNY", Unit: 100}, testUSD}} {
+		if _, err := ConvertCross(pair[0], pair[1], decimal.NewFromInt(1), AutoSide, Spot, money.HalfUp); !errors.Is(err, ErrUnsupportedPair) {
+			t.Errorf("ConvertCross(%s, %s) error = %v, want ErrUnsupportedPair", pair[0].Code, pair[1].Code, err)
+		}
+	}
+	if _, err := ConvertCross(testUSD, testEmpty, decimal.NewFromInt(1), AutoSide, Spot, money.HalfUp); !errors.Is(err, ErrNoPrice) {
+		t.Errorf("ConvertCross to quote without prices error = %v, want ErrNoPrice", err)
+	}
+}
@@ -0,0 +1,220 @@
+package bank
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"aki.telegram.bot.fxrate/currency"
+	"aki.telegram.bot.fxrate/money"
+)
+
+// Rule 一条优惠规则，作用于同一方式（现汇或现钞）的一对买入/卖出价
+type Rule struct {
+	SpreadOff decimal.Decimal `json:"spread_off"` // 点差优惠比例：0.5 即买卖价各向中间价收拢一半（5 折）
+	Pips      decimal.Decimal `json:"pips"`       // 在比例优惠之外再固定优惠的价格，按每100外币计
+	SpotOnly  bool            `json:"spot_only"`  // 只优惠现汇，现钞沿用基础牌价
+}
+
+// Programme 基于某家银行牌价打折得到的衍生来源（卡种、客户等级、代发工资户……）
+type Programme struct {
+	Key        string          `json:"key"`  // 命令名，如 "hy"
+	Name       string          `json:"name"` // 中文名，如 "寰宇人生"
+	Base       string          `json:"base"` // 基础来源 key，须已注册
+	Rule                       // 默认规则
+	Currencies map[string]Rule `json:"currencies"` // 按币种覆盖默认规则，键为币种代码或名称
+}
+
+// RuleFor 取某币种适用的规则
+func (p *Programme) RuleFor(code string) Rule {
+	code = currency.Normalize(code)
+	for k, r := range p.Currencies {
+		if currency.Normalize(k) == code {
+			return r
+		}
+	}
+	return p.Rule
+}
+
+// Describe 一句话描述默认规则，用于展示
+func (p *Programme) Describe() string {
+	base := p.Base
+	if bp, ok := Lookup(p.Base); ok {
+		base = bp.Name()
+	}
+	var parts []string
+	if p.SpreadOff.IsPositive() {
+		parts = append(parts, fmt.Sprintf("点差优惠 %s%%", p.SpreadOff.Mul(decimal.NewFromInt(100)).String()))
+	}
+	if p.Pips.IsPositive() {
+		parts = append(parts, fmt.Sprintf("每100外币再优惠 %s", p.Pips.String()))
+	}
+	if p.SpotOnly {
+		parts = append(parts, "仅现汇")
+	}
+	if len(p.Currencies) > 0 {
+		parts = append(parts, "部分币种另有规则")
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "无优惠")
+	}
+	return fmt.Sprintf("基于%s牌价，%s", base, strings.Join(parts, "，"))
+}
+
+// apply 对一行牌价应用规则；某一对价格不全时这一对都视为缺失，免得把原价当成优惠价。
+// 点差优惠不改变中间价，沿用基础来源的
+func (r Rule) apply(q Quote) Quote {
+	q.BuySpot, q.SellSpot = r.applyPair(q.BuySpot, q.SellSpot, q.Unit)
+	if !r.SpotOnly {
+		q.BuyCash, q.SellCash = r.applyPair(q.BuyCash, q.SellCash, q.Unit)
+	}
+	return q
+}
+
+// applyPair 买入价、卖出价向中间价收拢，收拢后不越过中间价，保留 4 位小数
+func (r Rule) applyPair(buy, sell decimal.NullDecimal, unit int64) (decimal.NullDecimal, decimal.NullDecimal) {
+	if !buy.Valid || !sell.Valid {
+		return decimal.NullDecimal{}, decimal.NullDecimal{}
+	}
+	if unit <= 0 {
+		unit = 100
+	}
+	mid := money.Mid(buy.Decimal, sell.Decimal)
+	pips := r.Pips.Mul(decimal.NewFromInt(unit)).Div(decimal.NewFromInt(100))
+
+	newBuy := buy.Decimal.Add(mid.Sub(buy.Decimal).Mul(r.SpreadOff)).Add(pips)
+	newSell := sell.Decimal.Sub(sell.Decimal.Sub(mid).Mul(r.SpreadOff)).Sub(pips)
+	newBuy = decimal.Min(newBuy, mid)
+	newSell = decimal.Max(newSell, mid)
+
+	return decimal.NewNullDecimal(money.RoundTo(newBuy, money.RateDigits, money.HalfUp)),
+		decimal.NewNullDecimal(money.RoundTo(newSell, money.RateDigits, money.HalfUp))
+}
+
+// programmeProvider 把 Programme 包装成 Provider
+type programmeProvider struct {
+	p Programme
+}
+
+func (pp *programmeProvider) Key() string  { return pp.p.Key }
+func (pp *programmeProvider) Name() string { return pp.p.Name }
+
+func (pp *programmeProvider) Currencies() []string {
+	if base, ok := Lookup(pp.p.Base); ok {
+		return base.Currencies()
+	}
+	return nil
+}
+
+// Snapshot 取基础来源的快照（走基础来源的缓存）后逐行打折
+func (pp *programmeProvider) Snapshot(ctx context.Context) (*Snapshot, error) {
+	base, ok := Lookup(pp.p.Base)
+	if !ok {
+		return nil, fmt.Errorf("bank: programme %q: unknown base %q", pp.p.Key, pp.p.Base)
+	}
+	snap, err := base.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Quote, 0, len(snap.Quotes))
+	for _, q := range snap.Quotes {
+		out = append(out, pp.p.RuleFor(q.Code).apply(q))
+	}
+	return &Snapshot{
+		Bank:        pp.p.Key,
+		Quotes:      out,
+		ReleaseTime: snap.ReleaseTime,
+		FetchedAt:   snap.FetchedAt,
+	}, nil
+}
+
+// LookupProgramme 按 key 查找优惠方案
+func LookupProgramme(key string) (*Programme, bool) {
+	p, ok := Lookup(key)
+	if !ok {
+		return nil, false
+	}
+	pp, ok := p.(*programmeProvider)
+	if !ok {
+		return nil, false
+	}
+	return &pp.p, true
+}
+
+// Programmes 按注册顺序返回全部优惠方案
+func Programmes() []*Programme {
+	var out []*Programme
+	for _, p := range Providers() {
+		if pp, ok := p.(*programmeProvider); ok {
+			out = append(out, &pp.p)
+		}
+	}
+	return out
+}
+
+// programmeKey key 同时作为 Telegram 命令名，只能用小写字母、数字和下划线
+var programmeKey = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+
+// RegisterProgramme 校验并注册一个优惠方案；与 Register 不同，出错时返回 error 而不是 panic，
+// 配置文件写错不应让进程起不来
+func RegisterProgramme(p Programme) error {
+	p.Key = strings.ToLower(strings.TrimSpace(p.Key))
+	switch {
+	case !programmeKey.MatchString(p.Key):
+		return fmt.Errorf("bank: programme key %q is invalid", p.Key)
+	case strings.TrimSpace(p.Name) == "":
+		return fmt.Errorf("bank: programme %q has no name", p.Key)
+	}
+	if _, dup := Lookup(p.Key); dup {
+		return fmt.Errorf("bank: programme %q conflicts with an existing provider", p.Key)
+	}
+	if _, ok := Lookup(p.Base); !ok {
+		return fmt.Errorf("bank: programme %q: unknown base %q", p.Key, p.Base)
+	}
+	for _, r := range append([]Rule{p.Rule}, mapValues(p.Currencies)...) {
+		if r.SpreadOff.IsNegative() || r.SpreadOff.GreaterThan(decimal.NewFromInt(1)) || r.Pips.IsNegative() {
+			return fmt.Errorf("bank: programme %q: spread_off must be within [0, 1] and pips must not be negative", p.Key)
+		}
+	}
+	Register(&programmeProvider{p: p})
+	return nil
+}
+
+// LoadProgrammes 从 JSON 文件加载优惠方案（数组），path 为空时什么也不做。
+// 文件示例：
+//
+//	[{"key": "zzb", "name": "招行朝朝宝", "base": "cmb", "spread_off": 0.3,
+//	  "currencies": {"USD": {"spread_off": 0.5}, "JPY": {"pips": 0.02, "spot_only": true}}}]
+func LoadProgrammes(path string) error {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var ps []Programme
+	if err := json.Unmarshal(data, &ps); err != nil {
+		return fmt.Errorf("bank: parse %s: %w", path, err)
+	}
+	for _, p := range ps {
+		if err := RegisterProgramme(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func mapValues[K comparable, V any](m map[K]V) []V {
+	out := make([]V, 0, len(m))
+	for _, v := range m {
+		out = append(out, v)
+	}
+	return out
+}
@@ -0,0 +1,173 @@
+package bank
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func dec(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+// unregister 测试结束时移除注册的来源，免得 -count 重跑时报重名
+func unregister(t *testing.T, key string) {
+	t.Cleanup(func() {
+		registryMu.Lock()
+		defer registryMu.Unlock()
+		delete(registry, key)
+		regOrder = slices.DeleteFunc(regOrder, func(k string) bool { return k == key })
+	})
+}
+
+func TestRuleApplyPair(t *testing.T) {
+	tests := []struct {
+		name              string
+		rule              Rule
+		buy, sell         string
+		unit              int64
+		wantBuy, wantSell string // 空表示缺失
+	}{
+		{"无优惠", Rule{}, "700", "710", 100, "700", "710"},
+		{"点差 5 折", Rule{SpreadOff: dec("0.5")}, "700", "710", 100, "702.5", "707.5"},
+		{"固定优惠", Rule{Pips: dec("1")}, "700", "710", 100, "701", "709"},
+		{"比例加固定", Rule{SpreadOff: dec("0.5"), Pips: dec("1")}, "700", "710", 100, "703.5", "706.5"},
+		{"不越过中间价", Rule{SpreadOff: dec("1"), Pips: dec("5")}, "700", "710", 100, "705", "705"},
+		{"固定优惠按每100外币折算", Rule{Pips: dec("0.5")}, "7.0000", "7.1000", 1, "7.005", "7.095"},
+		{"每1外币也不越过中间价", Rule{Pips: dec("10")}, "7.00", "7.10", 1, "7.05", "7.05"},
+		{"保留 4 位小数", Rule{SpreadOff: dec("0.5")}, "7.0001", "7.0004", 1, "7.0002", "7.0003"},
+		{"基数缺失按 100", Rule{Pips: dec("1")}, "700", "710", 0, "701", "709"},
+		{"缺买入价", Rule{SpreadOff: dec("0.5")}, "", "710", 100, "", ""},
+		{"缺卖出价", Rule{SpreadOff: dec("0.5")}, "700", "", 100, "", ""},
+	}
+	for _, tt := range tests {
+		buy, sell := tt.rule.applyPair(price(tt.buy), price(tt.sell), tt.unit)
+		for _, c := range []struct {
+			side string
+			got  decimal.NullDecimal
+			want string
+		}{{"buy", buy, tt.wantBuy}, {"sell", sell, tt.wantSell}} {
+			switch {
+			case c.want == "" && c.got.Valid:
+				t.Errorf("%s: %s = %s, want missing", tt.name, c.side, c.got.Decimal)
+			case c.want != "" && (!c.got.Valid || !c.got.Decimal.Equal(dec(c.want))):
+				t.Errorf("%s: %s = %v, want %s", tt.name, c.side, c.got, c.want)
+			}
+		}
+	}
+}
+
+func TestRuleApply(t *testing.T) {
+	q := Quote{Code: "HKD", Unit: 100,
+		BuySpot: price("90"), SellSpot: price("92"), BuyCash: price("89"), SellCash: price("92"), Middle: price("91.2")}
+
+	got := Rule{SpreadOff: dec("0.5"), SpotOnly: true}.apply(q)
+	if !got.BuySpot.Decimal.Equal(dec("90.5")) || !got.SellSpot.Decimal.Equal(dec("91.5")) {
+		t.Errorf("spot = %s/%s, want 90.5/91.5", got.BuySpot.Decimal, got.SellSpot.Decimal)
+	}
+	if got.BuyCash != q.BuyCash || got.SellCash != q.SellCash {
+		t.Errorf("spot-only rule changed cash prices: %s/%s", got.BuyCash.Decimal, got.SellCash.Decimal)
+	}
+	if got.Middle != q.Middle {
+		t.Errorf("Middle = %v, want the base middle %s", got.Middle, q.Middle.Decimal)
+	}
+
+	got = Rule{SpreadOff: dec("0.5")}.apply(q)
+	if !got.BuyCash.Decimal.Equal(dec("89.75")) || !got.SellCash.Decimal.Equal(dec("91.25")) {
+		t.Errorf("cash = %s/%s, want 89.75/91.25", got.BuyCash.Decimal, got.SellCash.Decimal)
+	}
+}
+
+func TestProgrammeRuleFor(t *testing.T) {
+	usd, jpy, def := Rule{SpreadOff: dec("0.5")}, Rule{Pips: dec("0.02"), SpotOnly: true}, Rule{SpreadOff: dec("0.3")}
+	p := Programme{Rule: def, Currencies: map[string]Rule{"USD": usd, "日元": jpy}}
+	tests := []struct {
+		code string
+		want Rule
+	}{
+		{"USD", usd},
+		{"usd", usd},
+		{"JPY", jpy}, // 键写中文名也能对上代码
+		{"HKD", def},
+	}
+	for _, tt := range tests {
+		got := p.RuleFor(tt.code)
+		if !got.SpreadOff.Equal(tt.want.SpreadOff) || !got.Pips.Equal(tt.want.Pips) || got.SpotOnly != tt.want.SpotOnly {
+			t.Errorf("RuleFor(%q) = %+v, want %+v", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestRegisterProgrammeInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		p    Programme
+		want string
+	}{
+		{"key 含连字符", Programme{Key: "bad-key", Name: "x", Base: "cib"}, "invalid"},
+		{"key 为中文", Programme{Key: "寰宇", Name: "x", Base: "cib"}, "invalid"},
+		{"key 为空", Programme{Key: " ", Name: "x", Base: "cib"}, "invalid"},
+		{"key 过长", Programme{Key: strings.Repeat("a", 33), Name: "x", Base: "cib"}, "invalid"},
+		{"没有名称", Programme{Key: "t_noname", Base: "cib"}, "no name"},
+		{"与银行重名", Programme{Key: "CIB", Name: "x", Base: "boc"}, "conflicts"},
+		{"基础来源不存在", Programme{Key: "t_nobase", Name: "x", Base: "nope"}, "unknown base"},
+		{"比例超过 1", Programme{Key: "t_off", Name: "x", Base: "cib", Rule: Rule{SpreadOff: dec("1.5")}}, "spread_off"},
+		{"币种规则为负", Programme{Key: "t_pips", Name: "x", Base: "cib",
+			Currencies: map[string]Rule{"USD": {Pips: dec("-1")}}}, "spread_off"},
+	}
+	for _, tt := range tests {
+		err := RegisterProgramme(tt.p)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want containing %q", tt.name, err, tt.want)
+		}
+		if _, ok := LookupProgramme(tt.p.Key); ok && tt.want != "conflicts" {
+			t.Errorf("%s: invalid programme was registered", tt.name)
+		}
+	}
+}
+
+func TestLoadProgrammes(t *testing.T) {
+	if err := LoadProgrammes(" "); err != nil {
+		t.Errorf("LoadProgrammes(empty) = %v", err)
+	}
+	dir := t.TempDir()
+	if err := LoadProgrammes(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("LoadProgrammes(missing file) succeeded")
+	}
+
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	if err := LoadProgrammes(write("bad.json", `{"key": "t_obj"}`)); err == nil {
+		t.Error("LoadProgrammes(object instead of array) succeeded")
+	}
+	if err := LoadProgrammes(write("invalid.json", `[{"key": "t-dash", "name": "x", "base": "cmb"}]`)); err == nil {
+		t.Error("LoadProgrammes(invalid key) succeeded")
+	}
+
+	unregister(t, "t_zzb")
+	path := write("ok.json", `[{"key": " T_ZZB ", "name": "测试朝朝宝", "base": "cmb", "spread_off": 0.3,
+		"currencies": {"USD": {"spread_off": 0.5}, "JPY": {"pips": 0.02, "spot_only": true}}}]`)
+	if err := LoadProgrammes(path); err != nil {
+		t.Fatal(err)
+	}
+	p, ok := LookupProgramme("t_zzb")
+	if !ok {
+		t.Fatal("programme t_zzb not registered")
+	}
+	if p.Name != "测试朝朝宝" || p.Base != "cmb" || !p.SpreadOff.Equal(dec("0.3")) {
+		t.Errorf("programme = %+v", p)
+	}
+	if r := p.RuleFor("JPY"); !r.Pips.Equal(dec("0.02")) || !r.SpotOnly {
+		t.Errorf("RuleFor(JPY) = %+v", r)
+	}
+	if r := p.RuleFor("USD"); !r.SpreadOff.Equal(dec("0.5")) {
+		t.Errorf("RuleFor(USD) = %+v", r)
+	}
+}
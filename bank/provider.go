@@ -0,0 +1,112 @@
+package bank
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"aki.telegram.bot.fxrate/currency"
+)
+
+// Provider 统一的牌价来源
+// 每家银行（或衍生卡种）实现一次并在 init 中 Register，
+// 命令、对比等功能直接遍历注册表，无需再逐个写适配。
+type Provider interface {
+	Key() string          // 短名，用于命令与参数，如 "boc"
+	Name() string         // 中文名，如 "中国银行"
+	Currencies() []string // 支持的币种代码（大写），以实际牌价为准
+	// Snapshot 一次抓取完整牌价表
+	Snapshot(ctx context.Context) (*Snapshot, error)
+}
+
+// Snapshot 某来源一次抓取得到的全部币种
+type Snapshot struct {
+	Bank        string    // 来源 key
+	Quotes      []Quote   // 全部币种行
+	ReleaseTime time.Time // 页面级发布时间（Asia/Shanghai）
+	FetchedAt   time.Time // 抓取时间
+}
+
+func newSnapshot(bank string, quotes []Quote, releaseTime string, fetchedAt time.Time) *Snapshot {
+	return &Snapshot{
+		Bank:        bank,
+		Quotes:      quotes,
+		ReleaseTime: parseReleaseTime(releaseTime),
+		FetchedAt:   fetchedAt,
+	}
+}
+
+// Find 在快照中查找单个币种
+func (s *Snapshot) Find(query string) (*Quote, bool) {
+	return FindQuote(s.Quotes, query)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Provider{}
+	regOrder   []string
+)
+
+// Register 注册一个来源，key 重复时 panic（与 database/sql 的驱动注册一致）
+func Register(p Provider) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	key := strings.ToLower(p.Key())
+	if _, dup := registry[key]; dup {
+		panic(fmt.Sprintf("bank: Register called twice for provider %q", key))
+	}
+	registry[key] = p
+	regOrder = append(regOrder, key)
+}
+
+// Lookup 按 key 查找来源（不区分大小写）
+func Lookup(key string) (Provider, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	p, ok := registry[strings.ToLower(strings.TrimSpace(key))]
+	return p, ok
+}
+
+// Providers 按注册顺序返回全部来源
+func Providers() []Provider {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	out := make([]Provider, 0, len(regOrder))
+	for _, k := range regOrder {
+		out = append(out, registry[k])
+	}
+	return out
+}
+
+// FetchQuote 拉取 p 的快照并查找单个币种
+func FetchQuote(ctx context.Context, p Provider, query string) (*Quote, bool, error) {
+	snap, err := p.Snapshot(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	q, ok := snap.Find(query)
+	return q, ok, nil
+}
+
+// FindQuote 在牌价表中查找币种
+// query 须能被 currency.Resolve 唯一识别，歧义或无法识别时视为未找到；
+// 需要提示候选的调用方应先自行调用 currency.Resolve。
+func FindQuote(quotes []Quote, query string) (*Quote, bool) {
+	return findRow(quotes, query, func(q *Quote) string { return q.Code })
+}
+
+// findRow 通过币种注册表识别 query 后按代码精确比对
+func findRow[T any](rows []T, query string, code func(*T) string) (*T, bool) {
+	c, err := currency.Resolve(query)
+	if err != nil {
+		return nil, false
+	}
+	for i := range rows {
+		if strings.EqualFold(code(&rows[i]), c.Code) {
+			return &rows[i], true
+		}
+	}
+	return nil, false
+}
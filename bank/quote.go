@@ -0,0 +1,149 @@
+package bank
+
+import (
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Shanghai 牌价发布时间统一使用的时区（无夏令时，直接用固定时区，免得容器里缺 tzdata）
+var Shanghai = time.FixedZone("CST", 8*3600)
+
+// Quote 各来源统一的单行牌价
+// 价格为精确小数，Valid=false 表示该来源没有这一项报价。
+type Quote struct {
+	Code        string              // ISO 4217 代码，如 "USD"；识别不出时为空
+	Name        string              // 币种中文名（来源原文）
+	Unit        int64               // 牌价基数：每 Unit 外币兑人民币（1 或 100）
+	BuySpot     decimal.NullDecimal // 现汇买入价
+	BuyCash     decimal.NullDecimal // 现钞买入价
+	SellSpot    decimal.NullDecimal // 现汇卖出价
+	SellCash    decimal.NullDecimal // 现钞卖出价
+	Middle      decimal.NullDecimal // 中间价/折算价/参考汇率
+	ReleaseTime time.Time           // 发布时间（Asia/Shanghai），未知时为零值
+}
+
+// Field 牌价中的某一项
+type Field int
+
+const (
+	BuySpot Field = iota
+	BuyCash
+	SellSpot
+	SellCash
+	Middle
+)
+
+// Fields 全部牌价项，按展示顺序
+var Fields = []Field{BuySpot, BuyCash, SellSpot, SellCash, Middle}
+
+// Label 中文名
+func (f Field) Label() string {
+	switch f {
+	case BuySpot:
+		return "现汇买入价"
+	case BuyCash:
+		return "现钞买入价"
+	case SellSpot:
+		return "现汇卖出价"
+	case SellCash:
+		return "现钞卖出价"
+	case Middle:
+		return "中间价"
+	default:
+		return "未知"
+	}
+}
+
+// Get 取某一项原始报价（按 q.Unit 计）
+func (q *Quote) Get(f Field) decimal.NullDecimal {
+	switch f {
+	case BuySpot:
+		return q.BuySpot
+	case BuyCash:
+		return q.BuyCash
+	case SellSpot:
+		return q.SellSpot
+	case SellCash:
+		return q.SellCash
+	case Middle:
+		return q.Middle
+	default:
+		return decimal.NullDecimal{}
+	}
+}
+
+// Per 取某一项并折算为“每 unit 外币”
+func (q *Quote) Per(f Field, unit int64) decimal.NullDecimal {
+	v := q.Get(f)
+	if !v.Valid || q.Unit <= 0 || q.Unit == unit {
+		return v
+	}
+	return decimal.NewNullDecimal(v.Decimal.Mul(decimal.NewFromInt(unit)).Div(decimal.NewFromInt(q.Unit)))
+}
+
+// FormatPrice 展示价格，缺失为 "-"
+func FormatPrice(v decimal.NullDecimal) string {
+	if !v.Valid {
+		return "-"
+	}
+	return v.Decimal.String()
+}
+
+// FormatTime 展示发布时间，未知为 "-"
+func FormatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.In(Shanghai).Format("2006-01-02 15:04:05")
+}
+
+// parsePrice 解析页面上的价格，"-"、空串、0 及非法值视为缺失
+func parsePrice(s string) decimal.NullDecimal {
+	s = strings.TrimSpace(strings.ReplaceAll(s, ",", ""))
+	if s == "" || s == "-" || s == "--" {
+		return decimal.NullDecimal{}
+	}
+	d, err := decimal.NewFromString(s)
+	if err != nil || !d.IsPositive() {
+		return decimal.NullDecimal{}
+	}
+	return decimal.NewNullDecimal(d)
+}
+
+var releaseTimeLayouts = []string{
+	"2006.01.02 15:04:05",
+	"2006.01.02 15:04",
+	"2006.01.02",
+	"20060102 150405",
+	"20060102 15:04:05",
+	"20060102",
+}
+
+// parseReleaseTime 解析各家五花八门的发布时间：
+// “2025.10.16 10:30:00”、“2025-10-16 10:30:00”、“2025年10月16日 10:30:00”、“20251016 103000” 等
+func parseReleaseTime(s string) time.Time {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "-" {
+		return time.Time{}
+	}
+	r := strings.NewReplacer("年", ".", "月", ".", "日", " ", "-", ".", "/", ".")
+	s = strings.Join(strings.Fields(r.Replace(s)), " ")
+	s = strings.TrimSuffix(s, ".")
+	for _, layout := range releaseTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, Shanghai); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// normCode 规范化来源给出的币种代码，"-" 视为未知
+func normCode(s string) string {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "-" {
+		return ""
+	}
+	return s
+}
@@ -9,6 +9,10 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/shopspring/decimal"
+
+	"aki.telegram.bot.fxrate/currency"
 )
 
 const unionpayURL = "https://m.unionpayintl.com/jfimg/"
@@ -33,56 +37,17 @@ type UnionpayResponse struct {
 
 // UnionpayExchangeRate 单条汇率数据（导出供外部使用）
 type UnionpayExchangeRate struct {
-	TransCur string  `json:"transCur"` // 目标币种（这里表示被等式左侧的币种）
-	BaseCur  string  `json:"baseCur"`  // 基准币种（等式右侧的币种）
-	RateData float64 `json:"rateData"` // 汇率：1 TransCur = RateData BaseCur
-}
-
-// UnionpayCodeToCN 币种代码到中文名的映射
-var UnionpayCodeToCN = map[string]string{
-	"CNY": "人民币",
-	"USD": "美元",
-	"HKD": "港币",
-	"EUR": "欧元",
-	"GBP": "英镑",
-	"JPY": "日元",
-	"AUD": "澳大利亚元",
-	"CAD": "加拿大元",
-	"SGD": "新加坡元",
-	"NZD": "新西兰元",
-	"CHF": "瑞士法郎",
-	"THB": "泰国铢",
-	"TWD": "新台币",
-	"KRW": "韩国元",
-	"PHP": "菲律宾比索",
-	"IDR": "印尼卢比",
-	"INR": "印度卢比",
-	"RUB": "卢布",
-	"ZAR": "南非兰特",
-	"AED": "阿联酋迪拉姆",
-	"SAR": "沙特里亚尔",
-	"MYR": "马来西亚林吉特",
-	"VND": "越南盾",
-	"BRL": "巴西雷亚尔",
-	"MXN": "墨西哥比索",
-	"TRY": "土耳其里拉",
-}
-
-// GetCurrencyName 获取币种中文名，如果没有映射则返回代码本身
-func GetCurrencyName(code string) string {
-	code = strings.ToUpper(strings.TrimSpace(code))
-	if name, ok := UnionpayCodeToCN[code]; ok {
-		return name
-	}
-	return code
+	TransCur string          `json:"transCur"` // 目标币种（这里表示被等式左侧的币种）
+	BaseCur  string          `json:"baseCur"`  // 基准币种（等式右侧的币种）
+	RateData decimal.Decimal `json:"rateData"` // 汇率：1 TransCur = RateData BaseCur
 }
 
 // GetUnionPayRate 获取指定货币对的汇率（扣账币种 -> 交易币种）
 // debitCur: 扣账币种，transCur: 交易币种
 // 仅使用JSON中的直接汇率（TransCur=debitCur 且 BaseCur=transCur）。未找到则返回 ErrUnionPayRateNotFound。
 func GetUnionPayRate(ctx context.Context, debitCur, transCur string) (*UniopayRate, bool, error) {
-	debitCur = strings.ToUpper(strings.TrimSpace(debitCur))
-	transCur = strings.ToUpper(strings.TrimSpace(transCur))
+	debitCur = currency.Normalize(debitCur)
+	transCur = currency.Normalize(transCur)
 
 	if transCur == "" {
 		return nil, false, fmt.Errorf("unionpay: empty transaction currency")
@@ -94,26 +59,58 @@ func GetUnionPayRate(ctx context.Context, debitCur, transCur string) (*UniopayRa
 		return nil, false, ErrUnionPayRateNotFound
 	}
 
-	resp, err := fetchUnionPayRates(ctx)
+	snap, err := GetUnionPaySnapshot(ctx)
 	if err != nil {
 		return nil, false, err
 	}
+	if rate, ok := snap.Find(debitCur, transCur); ok {
+		return rate, true, nil
+	}
+	return nil, false, ErrUnionPayRateNotFound
+}
+
+// UnionPaySnapshot 一次抓取得到的全部银联汇率
+type UnionPaySnapshot struct {
+	Rates       []UnionpayExchangeRate
+	ReleaseTime string    // 银联发布日期
+	FetchedAt   time.Time // 抓取时间
+}
+
+// GetUnionPaySnapshot 获取当日（或前一日）全部银联汇率（经缓存，返回值只读）
+func GetUnionPaySnapshot(ctx context.Context) (*UnionPaySnapshot, error) {
+	return cached(ctx, "unionpay", fetchUnionPaySnapshot)
+}
+
+func fetchUnionPaySnapshot(ctx context.Context) (*UnionPaySnapshot, error) {
+	resp, err := fetchUnionPayRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &UnionPaySnapshot{
+		Rates:       resp.ExchangeRateJson,
+		ReleaseTime: nz(resp.CurDate, "-"),
+		FetchedAt:   time.Now(),
+	}, nil
+}
 
-	for _, it := range resp.ExchangeRateJson {
+// Find 查找直接汇率（1 debitCur = ? transCur）
+func (s *UnionPaySnapshot) Find(debitCur, transCur string) (*UniopayRate, bool) {
+	debitCur = currency.Normalize(debitCur)
+	transCur = currency.Normalize(transCur)
+	for _, it := range s.Rates {
 		if strings.EqualFold(it.TransCur, debitCur) && strings.EqualFold(it.BaseCur, transCur) {
 			// 直接数据：1 debitCur = RateData transCur
-			rate := &UniopayRate{
+			return &UniopayRate{
 				BaseCur:     debitCur,
-				BaseName:    GetCurrencyName(debitCur),
+				BaseName:    currency.Name(debitCur),
 				TransCur:    transCur,
-				TransName:   GetCurrencyName(transCur),
-				Rate:        fmt.Sprintf("%g", it.RateData),
-				ReleaseTime: resp.CurDate,
-			}
-			return rate, true, nil
+				TransName:   currency.Name(transCur),
+				Rate:        it.RateData.String(),
+				ReleaseTime: s.ReleaseTime,
+			}, true
 		}
 	}
-	return nil, false, ErrUnionPayRateNotFound
+	return nil, false
 }
 
 // fetchUnionPayRates 从银联API获取原始JSON数据
@@ -185,3 +182,47 @@ func fetchUnionPayRates(ctx context.Context) (*UnionpayResponse, error) {
 
 	return nil, fmt.Errorf("获取汇率数据失败")
 }
+
+// ---- Provider ----
+
+func init() { Register(unionPayProvider{}) }
+
+// unionPayProvider 以“1 外币 = ? CNY”的银联汇率作为参考价，无买卖价
+type unionPayProvider struct{}
+
+func (unionPayProvider) Key() string  { return "unionpay" }
+func (unionPayProvider) Name() string { return "银联国际" }
+
+func (unionPayProvider) Currencies() []string {
+	return []string{
+		"AED", "AUD", "BRL", "CAD", "CHF", "EUR", "GBP", "HKD", "IDR", "INR", "JPY", "KRW", "MXN",
+		"MYR", "NZD", "PHP", "RUB", "SAR", "SGD", "THB", "TRY", "TWD", "USD", "VND", "ZAR",
+	}
+}
+
+func (unionPayProvider) Snapshot(ctx context.Context) (*Snapshot, error) {
+	snap, err := GetUnionPaySnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	releaseTime := parseReleaseTime(snap.ReleaseTime)
+	var out []Quote
+	for _, it := range snap.Rates {
+		if !strings.EqualFold(it.BaseCur, "CNY") || strings.EqualFold(it.TransCur, "CNY") {
+			continue
+		}
+		code := strings.ToUpper(it.TransCur)
+		out = append(out, Quote{
+			Code:        code,
+			Name:        currency.Name(code),
+			Unit:        1,
+			BuySpot:     decimal.NullDecimal{},
+			BuyCash:     decimal.NullDecimal{},
+			SellSpot:    decimal.NullDecimal{},
+			SellCash:    decimal.NullDecimal{},
+			Middle:      decimal.NewNullDecimal(it.RateData),
+			ReleaseTime: releaseTime,
+		})
+	}
+	return newSnapshot("unionpay", out, snap.ReleaseTime, snap.FetchedAt), nil
+}
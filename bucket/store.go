@@ -0,0 +1,145 @@
+package bucket
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// 通用的 JSON 记录表：<name> / <ID（大端）> -> T(JSON)。
+// 提醒、订阅、会话设置都按这种结构与牌价历史同库存放。
+
+// ErrNotFound 记录不存在（或已被删除）
+var ErrNotFound = errors.New("bucket: not found")
+
+// ErrLimit 新增时同组记录数已达上限
+var ErrLimit = errors.New("bucket: limit reached")
+
+// Store 一个 bucket 中的全部 T
+type Store[T any] struct {
+	db   *bolt.DB
+	name []byte
+}
+
+// New 在 db 中建好名为 name 的 bucket
+func New[T any](db *bolt.DB, name string) (*Store[T], error) {
+	s := &Store[T]{db: db, name: []byte(name)}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(s.name)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func idKey(id uint64) []byte {
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, id)
+	return k
+}
+
+// Insert 分配自增 ID（经 assign 写回 v）后保存新记录。
+// limit > 0 时，已有记录中满足 same 的达到 limit 条则不保存并返回 ErrLimit。
+func (s *Store[T]) Insert(v *T, assign func(id uint64), limit int, same func(*T) bool) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bk := tx.Bucket(s.name)
+		if limit > 0 {
+			n := 0
+			if err := bk.ForEach(func(_, data []byte) error {
+				var o T
+				if json.Unmarshal(data, &o) == nil && same(&o) {
+					n++
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+			if n >= limit {
+				return ErrLimit
+			}
+		}
+		id, err := bk.NextSequence()
+		if err != nil {
+			return err
+		}
+		assign(id)
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		return bk.Put(idKey(id), data)
+	})
+}
+
+// Put 按 id 写入，已有则覆盖
+func (s *Store[T]) Put(id uint64, v *T) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(s.name).Put(idKey(id), data)
+	})
+}
+
+// Update 覆盖已有记录；记录已被删除时返回 ErrNotFound，不会重新写回
+func (s *Store[T]) Update(id uint64, v *T) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bk := tx.Bucket(s.name)
+		if bk.Get(idKey(id)) == nil {
+			return ErrNotFound
+		}
+		return bk.Put(idKey(id), data)
+	})
+}
+
+// Get 按 id 取记录
+func (s *Store[T]) Get(id uint64) (*T, error) {
+	var out *T
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(s.name).Get(idKey(id))
+		if data == nil {
+			return ErrNotFound
+		}
+		out = new(T)
+		return json.Unmarshal(data, out)
+	})
+	return out, err
+}
+
+// Delete 删除记录
+func (s *Store[T]) Delete(id uint64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bk := tx.Bucket(s.name)
+		if bk.Get(idKey(id)) == nil {
+			return ErrNotFound
+		}
+		return bk.Delete(idKey(id))
+	})
+}
+
+// Filter 满足 keep 的记录，按 ID 升序
+func (s *Store[T]) Filter(keep func(*T) bool) ([]T, error) {
+	var out []T
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(s.name).ForEach(func(_, data []byte) error {
+			var v T
+			if err := json.Unmarshal(data, &v); err != nil {
+				return fmt.Errorf("%s: decode: %w", s.name, err)
+			}
+			if keep(&v) {
+				out = append(out, v)
+			}
+			return nil
+		})
+	})
+	return out, err
+}
@@ -0,0 +1,247 @@
+package chart
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// 画布尺寸与边距（像素）
+const (
+	width        = 960
+	height       = 540
+	marginLeft   = 72
+	marginRight  = 24
+	marginTop    = 44
+	marginBottom = 36
+)
+
+// ErrNoData 所有序列都没有点
+var ErrNoData = errors.New("chart: no data")
+
+// Point 序列中的一个点
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Series 一条折线；Label 只支持 ASCII（内置点阵字体没有中文）
+type Series struct {
+	Label  string
+	Points []Point // 须按时间升序
+}
+
+var (
+	background = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	gridColor  = color.RGBA{0xe6, 0xe6, 0xe6, 0xff}
+	axisColor  = color.RGBA{0x88, 0x88, 0x88, 0xff}
+	textColor  = color.RGBA{0x33, 0x33, 0x33, 0xff}
+
+	// palette 依次分配给各条折线
+	palette = []color.RGBA{
+		{0x1f, 0x77, 0xb4, 0xff},
+		{0xd6, 0x27, 0x28, 0xff},
+		{0x2c, 0xa0, 0x2c, 0xff},
+		{0xff, 0x7f, 0x0e, 0xff},
+		{0x94, 0x67, 0xbd, 0xff},
+		{0x8c, 0x56, 0x4b, 0xff},
+		{0xe3, 0x77, 0xc2, 0xff},
+		{0x17, 0xbe, 0xcf, 0xff},
+		{0xbc, 0xbd, 0x22, 0xff},
+		{0x7f, 0x7f, 0x7f, 0xff},
+	}
+)
+
+// Line 把若干序列画成阶梯折线图（牌价在两次发布之间保持不变），以 PNG 写入 w；
+// from、to 为横轴范围，零值时取数据本身的范围
+func Line(w io.Writer, series []Series, from, to time.Time) error {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	var first, last time.Time
+	for _, s := range series {
+		for _, p := range s.Points {
+			lo, hi = math.Min(lo, p.Value), math.Max(hi, p.Value)
+			if first.IsZero() || p.Time.Before(first) {
+				first = p.Time
+			}
+			if p.Time.After(last) {
+				last = p.Time
+			}
+		}
+	}
+	if math.IsInf(lo, 1) {
+		return ErrNoData
+	}
+	if from.IsZero() {
+		from = first
+	}
+	if to.IsZero() {
+		to = last
+	}
+	if !to.After(from) {
+		from, to = from.Add(-time.Hour), to.Add(time.Hour)
+	}
+
+	ticks, step := niceTicks(lo, hi, 6)
+	lo, hi = math.Min(lo, ticks[0]), math.Max(hi, ticks[len(ticks)-1])
+	if hi == lo {
+		lo, hi = lo-step, hi+step
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	plot := image.Rect(marginLeft, marginTop, width-marginRight, height-marginBottom)
+
+	x := func(t time.Time) int {
+		r := float64(t.Sub(from)) / float64(to.Sub(from))
+		return plot.Min.X + int(math.Round(r*float64(plot.Dx())))
+	}
+	y := func(v float64) int {
+		r := (v - lo) / (hi - lo)
+		return plot.Max.Y - int(math.Round(r*float64(plot.Dy())))
+	}
+
+	// 纵轴刻度与网格
+	digits := max(0, -int(math.Floor(math.Log10(step))))
+	for _, v := range ticks {
+		py := y(v)
+		hline(img, plot.Min.X, plot.Max.X, py, gridColor)
+		label := strconv.FormatFloat(v, 'f', digits, 64)
+		drawText(img, plot.Min.X-8-textWidth(label), py+4, label, textColor)
+	}
+	// 横轴刻度与网格
+	layout := "01-02"
+	if to.Sub(from) <= 48*time.Hour {
+		layout = "01-02 15:04"
+	}
+	const xTicks = 6
+	for i := 0; i <= xTicks; i++ {
+		t := from.Add(time.Duration(float64(to.Sub(from)) * float64(i) / xTicks))
+		px := x(t)
+		vline(img, px, plot.Min.Y, plot.Max.Y, gridColor)
+		label := t.Format(layout)
+		drawText(img, px-textWidth(label)/2, plot.Max.Y+18, label, textColor)
+	}
+	hline(img, plot.Min.X, plot.Max.X, plot.Max.Y, axisColor)
+	vline(img, plot.Min.X, plot.Min.Y, plot.Max.Y, axisColor)
+
+	// 折线
+	for i, s := range series {
+		c := palette[i%len(palette)]
+		for j := 1; j < len(s.Points); j++ {
+			prev, cur := s.Points[j-1], s.Points[j]
+			line(img, x(prev.Time), y(prev.Value), x(cur.Time), y(prev.Value), c)
+			line(img, x(cur.Time), y(prev.Value), x(cur.Time), y(cur.Value), c)
+		}
+		if len(s.Points) == 1 {
+			p := s.Points[0]
+			line(img, x(p.Time)-2, y(p.Value), x(p.Time)+2, y(p.Value), c)
+		}
+	}
+
+	// 图例
+	lx := plot.Min.X
+	for i, s := range series {
+		if len(s.Points) == 0 {
+			continue
+		}
+		c := palette[i%len(palette)]
+		draw.Draw(img, image.Rect(lx, 18, lx+14, 30), image.NewUniform(c), image.Point{}, draw.Src)
+		drawText(img, lx+20, 29, s.Label, textColor)
+		lx += 20 + textWidth(s.Label) + 24
+	}
+
+	return png.Encode(w, img)
+}
+
+// niceTicks 取 [lo, hi] 上约 n 个落在 1/2/5×10^k 整数倍上的刻度
+func niceTicks(lo, hi float64, n int) ([]float64, float64) {
+	span := hi - lo
+	if span <= 0 {
+		span = math.Max(math.Abs(lo)*0.01, 1e-4)
+	}
+	raw := span / float64(n)
+	mag := math.Pow(10, math.Floor(math.Log10(raw)))
+	step := mag * 10
+	for _, m := range []float64{1, 2, 5} {
+		if raw <= m*mag {
+			step = m * mag
+			break
+		}
+	}
+	var ticks []float64
+	for v := math.Floor(lo/step) * step; v <= hi+step/2; v += step {
+		ticks = append(ticks, v)
+	}
+	return ticks, step
+}
+
+var face = basicfont.Face7x13
+
+func drawText(img draw.Image, x, y int, s string, c color.Color) {
+	d := &font.Drawer{Dst: img, Src: image.NewUniform(c), Face: face, Dot: fixed.P(x, y)}
+	d.DrawString(s)
+}
+
+func textWidth(s string) int {
+	return font.MeasureString(face, s).Round()
+}
+
+func hline(img *image.RGBA, x0, x1, y int, c color.Color) {
+	for x := x0; x <= x1; x++ {
+		img.Set(x, y, c)
+	}
+}
+
+func vline(img *image.RGBA, x, y0, y1 int, c color.Color) {
+	for y := y0; y <= y1; y++ {
+		img.Set(x, y, c)
+	}
+}
+
+// line Bresenham 画线，线宽 2 像素
+func line(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		img.Set(x0, y0, c)
+		img.Set(x0+1, y0, c)
+		img.Set(x0, y0+1, c)
+		img.Set(x0+1, y0+1, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -3,8 +3,10 @@ package main
 import (
 	"context"
 	"fmt"
+	"html"
 	"strings"
 
+	"aki.telegram.bot.fxrate/bank"
 	"aki.telegram.bot.fxrate/tools"
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
@@ -13,6 +15,14 @@ import (
 )
 
 func HandleCommand(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.CallbackQuery != nil {
+		handleCallback(ctx, b, update.CallbackQuery)
+		return
+	}
+	if update.InlineQuery != nil {
+		commands.HandleInlineQuery(ctx, b, update.InlineQuery)
+		return
+	}
 	if update.Message == nil {
 		return
 	}
@@ -27,18 +37,17 @@ func HandleCommand(ctx context.Context, b *bot.Bot, update *models.Update) {
 		cmd = cmd[:atIndex]
 	}
 
-	userID := update.Message.From.ID
-
 	switch cmd {
 	case "/start":
 		CommandStart(ctx, b, update)
-		setCommandsForUser(ctx, b, userID)
+		// 频道消息、匿名管理员等没有 From
+		if update.Message.From != nil {
+			setCommandsForUser(ctx, b, update.Message.From.ID)
+		}
 	case "/boc":
 		commands.HandleBOCCommand(ctx, b, update)
 	case "/cib":
 		commands.HandleCIBCommand(ctx, b, update)
-	case "/hy":
-		commands.HandleCIBLifeCommand(ctx, b, update)
 	case "/cmb":
 		commands.HandleCMBCommand(ctx, b, update)
 	case "/citic":
@@ -55,13 +64,83 @@ func HandleCommand(ctx context.Context, b *bot.Bot, update *models.Update) {
 		commands.HandleXHMCCommand(ctx, b, update)
 	case "/gh":
 		commands.HandleXHMCCommand(ctx, b, update)
+	case "/xcmr":
+		commands.HandleXCMRCommand(ctx, b, update)
+	case "/xcmc":
+		commands.HandleXCMCCommand(ctx, b, update)
+	case "/zjj":
+		commands.HandleZJJCommand(ctx, b, update)
+	case "/xhjc":
+		commands.HandleXHJCCommand(ctx, b, update)
+	case "/xcjc":
+		commands.HandleXCJCCommand(ctx, b, update)
+	case "/best":
+		commands.HandleBestCommand(ctx, b, update)
+	case "/compare":
+		commands.HandleCompareCommand(ctx, b, update)
+	case "/spread":
+		commands.HandleSpreadCommand(ctx, b, update)
+	case "/history":
+		commands.HandleHistoryCommand(ctx, b, update)
+	case "/chart":
+		commands.HandleChartCommand(ctx, b, update)
+	case "/alert":
+		commands.HandleAlertCommand(ctx, b, update)
+	case "/digest":
+		commands.HandleDigestCommand(ctx, b, update)
+	case "/auto":
+		commands.HandleAutoCommand(ctx, b, update)
 	default:
+		if !strings.HasPrefix(cmd, "/") {
+			// 不带命令的消息交给自然语言换算，未开启或识别不了时不回应
+			commands.HandleNaturalText(ctx, b, update)
+			return
+		}
+		// 优惠方案（内置的 /hy 及配置文件加载的）按 key 直接作为命令
+		if _, ok := bank.LookupProgramme(strings.TrimPrefix(cmd, "/")); ok {
+			commands.HandleProgrammeCommand(ctx, b, update)
+		}
+	}
+}
+
+// handleCallback 处理内联按钮回调
+func handleCallback(ctx context.Context, b *bot.Bot, cq *models.CallbackQuery) {
+	switch {
+	case strings.HasPrefix(cq.Data, commands.BoardPrefix):
+		commands.HandleBoardCallback(ctx, b, cq)
+		return
+	case strings.HasPrefix(cq.Data, commands.QuotePrefix):
+		commands.HandleQuoteCallback(ctx, b, cq)
+		return
+	case !strings.HasPrefix(cq.Data, commands.RetryPrefix):
+		tools.AnswerCallbackQuery(ctx, b, cq.ID, "")
+		return
+	}
+	msg := cq.Message.Message
+	if msg == nil {
+		tools.AnswerCallbackQuery(ctx, b, cq.ID, "消息已过期，请重新发送命令")
 		return
 	}
+	tools.AnswerCallbackQuery(ctx, b, cq.ID, "")
+
+	// 候选提示用过即删，再把改写后的命令当作用户消息重新分发
+	text := strings.TrimPrefix(cq.Data, commands.RetryPrefix)
+	tools.DeleteMessage(ctx, b, msg.Chat.ID, msg.ID)
+	from := cq.From
+	HandleCommand(ctx, b, &models.Update{Message: &models.Message{
+		Text:            text,
+		Chat:            msg.Chat,
+		MessageThreadID: msg.MessageThreadID,
+		From:            &from,
+	}})
 }
 
 func CommandStart(ctx context.Context, b *bot.Bot, update *models.Update) {
 	nickname := tools.GetUserNickName(update)
+	var programmes strings.Builder
+	for _, p := range bank.Programmes() {
+		programmes.WriteString(fmt.Sprintf("/%s - %s\n", p.Key, html.EscapeString(p.Name)))
+	}
 	startReply := fmt.Sprintf(
 		"Welcome, %s!\n\n目前可用的指令:\n"+
 			"/start - 显示这条消息，更新命令列表\n"+
@@ -69,14 +148,25 @@ func CommandStart(ctx context.Context, b *bot.Bot, update *models.Update) {
 			"/cib - 兴业银行\n"+
 			"/cgb - 广发银行\n"+
 			"/citic - 中信银行\n"+
-			"/hy  - 寰宇人生借记卡\n"+
-			"/cmb - 招商银行\n\n"+
+			"/cmb - 招商银行\n"+
+			"%s\n"+
 			"/uniopay - 银联\n\n"+
 			"/xhmr [币种] [筛选数|银行] - 现汇买入对比\n"+
 			"也可以使用 /jh\n\n"+
 			"/xhmc [币种] [筛选数|银行] - 现汇卖出对比\n"+
 			"也可以使用 /gh\n\n"+
-			"Enjoy~ 💖", nickname,
+			"/xcmr /xcmc [币种] [筛选数|银行] - 现钞买入/卖出对比\n"+
+			"/zjj [币种] [筛选数|银行] - 中间价对比\n"+
+			"/xhjc /xcjc [币种] [筛选数|银行] - 现汇/现钞买卖价差对比\n\n"+
+			"/best [币种] [金额] [目标币种] - 各银行换汇结果排名\n"+
+			"/compare [币种] - 各银行全部牌价对比\n"+
+			"/spread [币种] [金额] - 买卖价差与往返成本排名\n"+
+			"/history [银行] [币种] [7d] - 历史牌价日线\n"+
+			"/chart [币种] [银行,银行] [7d] - 历史牌价走势图\n"+
+			"/alert add|move|best|beat ... - 价格提醒，/alert help 查看用法\n"+
+			"/digest add [09:30] [币种,币种] - 工作日定时汇总\n"+
+			"/auto on|off - 直接发送 \"100usd\"、\"1万日元\" 即可换算\n\n"+
+			"Enjoy~ 💖", nickname, programmes.String(),
 	)
 	tools.SendMessage(ctx, b, update.Message.Chat.ID, startReply, update.Message.MessageThreadID, "")
 }
@@ -88,11 +178,26 @@ func setCommandsForUser(ctx context.Context, b *bot.Bot, userID int64) {
 		{Command: "cib", Description: "兴业银行"},
 		{Command: "cgb", Description: "广发银行"},
 		{Command: "citic", Description: "中信银行"},
-		{Command: "hy", Description: "寰宇人生借记卡"},
 		{Command: "cmb", Description: "招商银行"},
 		{Command: "uniopay", Description: "银联"},
 		{Command: "xhmr", Description: "现汇买入对比"},
 		{Command: "xhmc", Description: "现汇卖出对比"},
+		{Command: "xcmr", Description: "现钞买入对比"},
+		{Command: "xcmc", Description: "现钞卖出对比"},
+		{Command: "zjj", Description: "中间价对比"},
+		{Command: "xhjc", Description: "现汇价差对比"},
+		{Command: "xcjc", Description: "现钞价差对比"},
+		{Command: "best", Description: "最优换汇"},
+		{Command: "compare", Description: "各银行牌价对比"},
+		{Command: "spread", Description: "买卖价差排名"},
+		{Command: "history", Description: "历史牌价"},
+		{Command: "chart", Description: "牌价走势图"},
+		{Command: "alert", Description: "价格提醒"},
+		{Command: "digest", Description: "定时汇总"},
+		{Command: "auto", Description: "自然语言换算开关"},
+	}
+	for _, p := range bank.Programmes() {
+		userCommands = append(userCommands, models.BotCommand{Command: p.Key, Description: p.Name})
 	}
 	params := &bot.SetMyCommandsParams{
 		Commands: userCommands,
@@ -1,142 +1,58 @@
 package commands
 
 import (
-    "context"
-    "fmt"
-    "strings"
+	"context"
+	"strings"
 
-    "github.com/go-telegram/bot"
-    "github.com/go-telegram/bot/models"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
 
-    "aki.telegram.bot.fxrate/bank"
-    "aki.telegram.bot.fxrate/tools"
+	"aki.telegram.bot.fxrate/tools"
 )
 
 func HandleCITICCommand(ctx context.Context, b *bot.Bot, update *models.Update) {
-    if update.Message == nil {
-        return
-    }
-    fields := strings.Fields(update.Message.Text)
-    if len(fields) < 2 {
-        tools.SendMessage(ctx, b, update.Message.Chat.ID,
-            "用法: /citic [币种] [金额] [目标币种]\n"+
-                "示例:\n"+
-                "/citic hkd - 查询中信银行港币（HKD）牌价\n"+
-                "/citic hkd 100 - 计算 100HKD 换算成 CNY\n"+
-                "/citic cny 100 hkd - 计算 100CNY 换算成 HKD",
-            update.Message.MessageThreadID, "")
-        return
-    }
-
-    if len(fields) == 2 {
-        handleCITICLookup(ctx, b, update, fields[1])
-        return
-    }
-
-    from := fields[1]
-    amountStr := fields[2]
-    to := "cny"
-    if len(fields) >= 4 {
-        to = fields[3]
-    }
-    amount, ok := ParseAmount(amountStr)
-    if !ok {
-        tools.SendMessage(ctx, b, update.Message.Chat.ID, "金额格式不正确，请输入数字，例如: 100 或 100.5", update.Message.MessageThreadID, "")
-        return
-    }
-    handleCITICConvert(ctx, b, update, from, to, amount)
-}
-
-func handleCITICLookup(ctx context.Context, b *bot.Bot, update *models.Update, q string) {
-    rate, found, err := bank.GetCITICRate(ctx, q)
-    if err != nil {
-        tools.LogError("CITIC fetch error: %v", err)
-        tools.SendMessage(ctx, b, update.Message.Chat.ID, "查询失败，请稍后再试。", update.Message.MessageThreadID, "")
-        return
-    }
-    if !found || rate == nil {
-        tools.SendMessage(ctx, b, update.Message.Chat.ID, "未找到该币种，请尝试币种代码（如: USD/HKD）或中文名。", update.Message.MessageThreadID, "")
-        return
-    }
-
-    msg := fmt.Sprintf(
-        "中信银行外汇牌价 — %s (%s)\n\n"+
-            "结汇买价(银行买入): %s\n"+
-            "购汇卖价(银行卖出): %s\n\n"+
-            "发布时间: %s",
-        rate.Name, rate.Symbol, rate.BuySpot, rate.SellSpot, rate.ReleaseTime,
-    )
-    tools.SendMessage(ctx, b, update.Message.Chat.ID, msg, update.Message.MessageThreadID, "")
-}
-
-// 外币 -> CNY：结汇
-// CNY -> 外币：购汇
-// 牌价单位为“每100外币”
-func handleCITICConvert(ctx context.Context, b *bot.Bot, update *models.Update, from, to string, amount float64) {
-    if amount < 0 {
-        tools.SendMessage(ctx, b, update.Message.Chat.ID, "金额不能为负数。", update.Message.MessageThreadID, "")
-        return
-    }
-
-    fromCode := UpperCurrency(from)
-    toCode := UpperCurrency(to)
-    unit := 100.0
-
-    if strings.EqualFold(fromCode, toCode) {
-        msg := fmt.Sprintf("%.2f %s = %.2f %s (同币种，无需换算)", amount, fromCode, amount, toCode)
-        tools.SendMessage(ctx, b, update.Message.Chat.ID, msg, update.Message.MessageThreadID, "")
-        return
-    }
-
-    // CNY -> 外币
-    if IsCNY(fromCode) && !IsCNY(toCode) {
-        rate, found, err := bank.GetCITICRate(ctx, toCode)
-        if err != nil {
-            tools.LogError("CITIC fetch error: %v", err)
-            tools.SendMessage(ctx, b, update.Message.Chat.ID, "查询失败，请稍后再试。", update.Message.MessageThreadID, "")
-            return
-        }
-        if !found || rate == nil {
-            tools.SendMessage(ctx, b, update.Message.Chat.ID, "未找到该币种，请检查输入的目标币种代码。", update.Message.MessageThreadID, "")
-            return
-        }
-        label := "购汇"
-        rateStr := rate.SellSpot
-        rateVal, ok := ParseRate(rate.SellSpot)
-        if !ok || rateVal <= 0 {
-            tools.SendMessage(ctx, b, update.Message.Chat.ID, "目标币种缺少有效的卖出价，无法换算。", update.Message.MessageThreadID, "")
-            return
-        }
-        out := amount / rateVal * unit
-        msg := FormatCNYToFX("中信银行", rate.Name, toCode, amount, out, label, rateStr, rate.ReleaseTime)
-        tools.SendMessage(ctx, b, update.Message.Chat.ID, msg, update.Message.MessageThreadID, "")
-        return
-    }
-
-    // 外币 -> CNY
-    if !IsCNY(fromCode) && IsCNY(toCode) {
-        rate, found, err := bank.GetCITICRate(ctx, fromCode)
-        if err != nil {
-            tools.LogError("CITIC fetch error: %v", err)
-            tools.SendMessage(ctx, b, update.Message.Chat.ID, "查询失败，请稍后再试。", update.Message.MessageThreadID, "")
-            return
-        }
-        if !found || rate == nil {
-            tools.SendMessage(ctx, b, update.Message.Chat.ID, "未找到该币种，请检查输入的源币种代码。", update.Message.MessageThreadID, "")
-            return
-        }
-        label := "结汇"
-        rateStr := rate.BuySpot
-        rateVal, ok := ParseRate(rate.BuySpot)
-        if !ok || rateVal <= 0 {
-            tools.SendMessage(ctx, b, update.Message.Chat.ID, "源币种缺少有效的买入价，无法换算。", update.Message.MessageThreadID, "")
-            return
-        }
-        out := amount * rateVal / unit
-        msg := FormatFXToCNY("中信银行", rate.Name, fromCode, amount, out, label, rateStr, rate.ReleaseTime)
-        tools.SendMessage(ctx, b, update.Message.Chat.ID, msg, update.Message.MessageThreadID, "")
-        return
-    }
-
-    tools.SendMessage(ctx, b, update.Message.Chat.ID, "暂不支持~", update.Message.MessageThreadID, "")
+	if update.Message == nil {
+		return
+	}
+	fields, opts := parseRateFlags(strings.Fields(update.Message.Text))
+	if len(fields) < 2 {
+		tools.SendMessage(ctx, b, update.Message.Chat.ID,
+			"用法: /citic [币种] [金额] [目标币种]\n"+
+				"示例:\n"+
+				"/citic hkd - 查询中信银行港币（HKD）牌价\n"+
+				"/citic all - 查看全部币种牌价\n"+
+				"/citic hkd 100 - 计算 100HKD 换算成 CNY\n"+
+				"/citic cny 100 hkd - 计算 100CNY 换算成 HKD\n"+
+				"/citic usd 100 hkd - 计算 100USD 经人民币换算成 HKD\n"+
+				rateFlagsUsage,
+			update.Message.MessageThreadID, "")
+		return
+	}
+
+	if len(fields) == 2 && strings.EqualFold(fields[1], "all") {
+		handleBoard(ctx, b, update, "citic")
+		return
+	}
+
+	if !resolveArgs(ctx, b, update, fields, 1, 3) {
+		return
+	}
+
+	if len(fields) == 2 {
+		handleQuote(ctx, b, update, "citic", fields[1])
+		return
+	}
+
+	from := fields[1]
+	amountStr := fields[2]
+	to := "cny"
+	if len(fields) >= 4 {
+		to = fields[3]
+	}
+	amount, ok := ParseAmount(amountStr)
+	if !ok {
+		tools.SendMessage(ctx, b, update.Message.Chat.ID, "金额格式不正确，请输入数字，例如: 100 或 100.5", update.Message.MessageThreadID, "")
+		return
+	}
+	handleConvert(ctx, b, update, "citic", from, to, amount, opts)
 }
@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"aki.telegram.bot.fxrate/bank"
+	"aki.telegram.bot.fxrate/tools"
+)
+
+// HandleProgrammeCommand 优惠方案（/hy 及配置文件里加载的方案）共用的命令，方案由命令名决定
+func HandleProgrammeCommand(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update.Message == nil {
+		return
+	}
+	fields, opts := parseRateFlags(strings.Fields(update.Message.Text))
+	key := strings.TrimPrefix(fields[0], "/")
+	if i := strings.Index(key, "@"); i != -1 {
+		key = key[:i]
+	}
+	prog, ok := bank.LookupProgramme(key)
+	if !ok {
+		return
+	}
+	key = prog.Key
+
+	if len(fields) < 2 {
+		tools.SendMessage(ctx, b, update.Message.Chat.ID,
+			fmt.Sprintf("%s（%s）\n\n", html.EscapeString(prog.Name), html.EscapeString(prog.Describe()))+
+				fmt.Sprintf("用法: /%s [币种] [金额] [目标币种]\n", key)+
+				"示例:\n"+
+				fmt.Sprintf("/%s hkd - 查询%s优惠价港币（HKD）牌价\n", key, html.EscapeString(prog.Name))+
+				fmt.Sprintf("/%s all - 查看全部币种牌价\n", key)+
+				fmt.Sprintf("/%s hkd 100 - 计算 100HKD 换算成 CNY\n", key)+
+				fmt.Sprintf("/%s cny 100 hkd - 计算 100CNY 换算成 HKD\n", key)+
+				fmt.Sprintf("/%s usd 100 hkd - 计算 100USD 经人民币换算成 HKD\n", key)+
+				rateFlagsUsage,
+			update.Message.MessageThreadID, "")
+		return
+	}
+
+	if len(fields) == 2 && strings.EqualFold(fields[1], "all") {
+		handleBoard(ctx, b, update, key)
+		return
+	}
+
+	// 仅 1 参数：查询
+	if !resolveArgs(ctx, b, update, fields, 1, 3) {
+		return
+	}
+
+	if len(fields) == 2 {
+		handleQuote(ctx, b, update, prog.Key, fields[1])
+		return
+	}
+
+	// 3 参数以上：换算 /<key> <from> <amount> [to]
+	from := fields[1]
+	amountStr := fields[2]
+	to := "cny"
+	if len(fields) >= 4 {
+		to = fields[3]
+	}
+	amount, ok := ParseAmount(amountStr)
+	if !ok {
+		tools.SendMessage(ctx, b, update.Message.Chat.ID, "金额格式不正确，请输入数字，例如: 100 或 100.5", update.Message.MessageThreadID, "")
+		return
+	}
+	handleConvert(ctx, b, update, key, from, to, amount, opts)
+}
@@ -7,8 +7,11 @@ import (
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
+	"github.com/shopspring/decimal"
 
 	"aki.telegram.bot.fxrate/bank"
+	"aki.telegram.bot.fxrate/currency"
+	"aki.telegram.bot.fxrate/money"
 	"aki.telegram.bot.fxrate/tools"
 )
 
@@ -16,19 +19,30 @@ func HandleUnionPayCommand(ctx context.Context, b *bot.Bot, update *models.Updat
 	if update.Message == nil {
 		return
 	}
-	fields := strings.Fields(update.Message.Text)
+	// 银联只有一个汇率，牌价选项没有意义，直接忽略
+	fields, _ := parseRateFlags(strings.Fields(update.Message.Text))
 	if len(fields) < 2 {
 		tools.SendMessage(ctx, b, update.Message.Chat.ID,
 			"用法: /unionpay [币种] [金额] [目标币种]\n"+
 				"语义:\n"+
 				"/unionpay hkd           -> 查询 1 HKD = ? CNY\n"+
+				"/unionpay all           -> 查看全部币种牌价\n"+
 				"/unionpay hkd 100       -> 100 HKD 换算成 CNY\n"+
 				"/unionpay hkd 100 usd   -> 100 HKD 换算成 USD\n",
 			update.Message.MessageThreadID, "")
 		return
 	}
 
+	if len(fields) == 2 && strings.EqualFold(fields[1], "all") {
+		handleBoard(ctx, b, update, "unionpay")
+		return
+	}
+
 	// 查询 /unionpay <fx>   => <fx> -> CNY
+	if !resolveArgs(ctx, b, update, fields, 1, 3) {
+		return
+	}
+
 	if len(fields) == 2 {
 		handleUnionPayLookup(ctx, b, update, fields[1])
 		return
@@ -51,7 +65,7 @@ func HandleUnionPayCommand(ctx context.Context, b *bot.Bot, update *models.Updat
 
 // handleUnionPayLookup 查询单个币种（<q> -> CNY）
 func handleUnionPayLookup(ctx context.Context, b *bot.Bot, update *models.Update, q string) {
-	debit := UpperCurrency(strings.TrimSpace(q))
+	debit := currency.Normalize(q)
 	trans := "CNY"
 
 	rate, found, err := bank.GetUnionPayRate(ctx, debit, trans)
@@ -69,7 +83,7 @@ func handleUnionPayLookup(ctx context.Context, b *bot.Bot, update *models.Update
 		"银联国际汇率 — %s -> %s\n\n"+
 			"1 %s = %s %s\n\n"+
 			"发布时间: %s",
-		bank.GetCurrencyName(debit), bank.GetCurrencyName(trans),
+		currency.Name(debit), currency.Name(trans),
 		debit, rate.Rate, trans,
 		rate.ReleaseTime,
 	)
@@ -80,23 +94,17 @@ func handleUnionPayLookup(ctx context.Context, b *bot.Bot, update *models.Update
 // 语义：
 // - /unionpay <fx> <amount>         =>  debit=<fx>, trans=CNY
 // - /unionpay <fx> <amount> <to>    =>  debit=<fx>, trans=<to>
-func handleUnionPayConvert(ctx context.Context, b *bot.Bot, update *models.Update, from, to string, amount float64) {
-	if amount < 0 {
+func handleUnionPayConvert(ctx context.Context, b *bot.Bot, update *models.Update, from, to string, amount decimal.Decimal) {
+	if amount.IsNegative() {
 		tools.SendMessage(ctx, b, update.Message.Chat.ID, "金额不能为负数。", update.Message.MessageThreadID, "")
 		return
 	}
 
-	fromCode := UpperCurrency(from)
-	toCode := UpperCurrency(to)
-
-	// 将 CNY 同义词规范为 CNY
-	if IsCNY(fromCode) {
-		fromCode = "CNY"
-	}
+	// 币种别名（人民币/rmb、港币/港纸……）统一规范为 ISO 代码
+	fromCode := currency.Normalize(from)
+	toCode := currency.Normalize(to)
 	if strings.TrimSpace(to) == "" {
 		toCode = "CNY"
-	} else if IsCNY(toCode) {
-		toCode = "CNY"
 	}
 
 	debit := fromCode
@@ -104,7 +112,7 @@ func handleUnionPayConvert(ctx context.Context, b *bot.Bot, update *models.Updat
 
 	// 同币种
 	if strings.EqualFold(debit, trans) {
-		msg := fmt.Sprintf("%.2f %s = %.2f %s (同币种，无需换算)", amount, debit, amount, trans)
+		msg := fmt.Sprintf("%s %s = %s %s (同币种，无需换算)", money.Format(amount, debit), debit, money.Format(amount, trans), trans)
 		tools.SendMessage(ctx, b, update.Message.Chat.ID, msg, update.Message.MessageThreadID, "")
 		return
 	}
@@ -121,18 +129,18 @@ func handleUnionPayConvert(ctx context.Context, b *bot.Bot, update *models.Updat
 	}
 
 	rateVal := mustParseRate(rate.Rate)
-	out := amount * rateVal
+	out := money.Round(amount.Mul(rateVal), trans, money.HalfUp)
 
 	// 使用 utils 的标准格式：仅在 CNY <-> 外币 时使用
-	if IsCNY(trans) && !IsCNY(debit) {
+	if currency.IsCNY(trans) && !currency.IsCNY(debit) {
 		// 外币 -> CNY
-		msg := FormatFXToCNY("银联国际", bank.GetCurrencyName(debit), debit, amount, out, "汇率", rate.Rate, rate.ReleaseTime)
+		msg := FormatFXToCNY("银联国际", currency.Name(debit), debit, amount, out, "汇率", rate.Rate, rate.ReleaseTime)
 		tools.SendMessage(ctx, b, update.Message.Chat.ID, msg, update.Message.MessageThreadID, "")
 		return
 	}
-	if IsCNY(debit) && !IsCNY(trans) {
+	if currency.IsCNY(debit) && !currency.IsCNY(trans) {
 		// CNY -> 外币
-		msg := FormatCNYToFX("银联国际", bank.GetCurrencyName(trans), trans, amount, out, "汇率", rate.Rate, rate.ReleaseTime)
+		msg := FormatCNYToFX("银联国际", currency.Name(trans), trans, amount, out, "汇率", rate.Rate, rate.ReleaseTime)
 		tools.SendMessage(ctx, b, update.Message.Chat.ID, msg, update.Message.MessageThreadID, "")
 		return
 	}
@@ -140,11 +148,11 @@ func handleUnionPayConvert(ctx context.Context, b *bot.Bot, update *models.Updat
 	// 外币 -> 外币：保留原先的通用格式
 	msg := fmt.Sprintf(
 		"按银联国际汇率换算: %s -> %s\n\n"+
-			"%.2f %s ≈ %.2f %s\n\n"+
+			"%s %s ≈ %s %s\n\n"+
 			"使用汇率: %s (1 %s = %s %s)\n"+
 			"发布时间: %s",
-		bank.GetCurrencyName(debit), bank.GetCurrencyName(trans),
-		amount, debit, out, trans,
+		currency.Name(debit), currency.Name(trans),
+		money.Format(amount, debit), debit, money.Format(out, trans), trans,
 		rate.Rate, debit, rate.Rate, trans,
 		rate.ReleaseTime,
 	)
@@ -156,8 +164,8 @@ func errorsIsRateNotFound(err error) bool {
 	return err != nil && strings.Contains(strings.ToLower(err.Error()), "direct rate not found")
 }
 
-// mustParseRate 将字符串汇率解析为浮点数（假定一定可用）
-func mustParseRate(s string) float64 {
+// mustParseRate 将字符串汇率解析为小数（假定一定可用）
+func mustParseRate(s string) decimal.Decimal {
 	v, _ := ParseRate(s)
 	return v
 }
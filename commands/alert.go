@@ -0,0 +1,456 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/shopspring/decimal"
+
+	"aki.telegram.bot.fxrate/alert"
+	"aki.telegram.bot.fxrate/bank"
+	"aki.telegram.bot.fxrate/currency"
+	"aki.telegram.bot.fxrate/history"
+	"aki.telegram.bot.fxrate/tools"
+)
+
+// alertUsage 按 HTML 发送，比较符号须写成实体
+const alertUsage = "用法（价格、价差均按每100外币）:\n" +
+	"/alert add [银行] [币种] [buy/sell/mid] [spot/cash] [&lt; 或 &gt;] [价格] - 价格越过阈值\n" +
+	"/alert move [银行] [币种] [buy/sell/mid] [spot/cash] [幅度%] [基准价] - 相对基准涨跌超过幅度，基准默认为当前价\n" +
+	"/alert best [币种] [buy/sell] [spot/cash] [30d] [银行] - 创 N 天最优，银行默认任一\n" +
+	"/alert beat [银行] [对比银行] [币种] [buy/sell] [spot/cash] [价差] - 比另一家银行优出价差\n" +
+	"/alert list - 查看本会话的提醒\n" +
+	"/alert del [编号|all] - 删除自己添加的提醒\n" +
+	"示例:\n" +
+	"/alert add boc jpy sell &lt; 4.70\n" +
+	"/alert move boc usd sell 0.3%\n" +
+	"/alert best hkd buy 30d\n" +
+	"/alert beat cmb boc usd sell 0.5\n" +
+	"未指定买入/卖出时，add 的 &lt; 看卖出价、&gt; 看买入价，其余看卖出价"
+
+// alertCond "< 4.70"、"<=4.70"、">720" 之类的条件
+var alertCond = regexp.MustCompile(`^(<=?|>=?)([0-9.,]+)$`)
+
+// HandleAlertCommand /alert add|list|del：价格提醒管理
+func HandleAlertCommand(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update == nil || update.Message == nil {
+		return
+	}
+	chatID, threadID := update.Message.Chat.ID, update.Message.MessageThreadID
+
+	store := alert.Default()
+	if store == nil {
+		tools.SendMessage(ctx, b, chatID, "未启用价格提醒（数据库不可用）。", threadID, "")
+		return
+	}
+
+	fields := strings.Fields(update.Message.Text)
+	sub := "list"
+	if len(fields) >= 2 {
+		sub = strings.ToLower(fields[1])
+	}
+	switch sub {
+	case "add", "new", "添加":
+		handleAlertAdd(ctx, b, update, store)
+	case "move", "涨跌":
+		handleAlertMove(ctx, b, update, store)
+	case "best", "最优":
+		handleAlertBest(ctx, b, update, store)
+	case "beat", "优于":
+		handleAlertBeat(ctx, b, update, store)
+	case "list", "ls", "列表":
+		handleAlertList(ctx, b, update, store)
+	case "del", "rm", "delete", "删除":
+		deleteOwned(ctx, b, update, store, ownedKind{Tag: "alert", Noun: "提醒", Command: "/alert"},
+			func(a *alert.Alert) (uint64, int64, int64) { return a.ID, a.ChatID, a.UserID }, fields[2:])
+	default:
+		tools.SendMessage(ctx, b, chatID, alertUsage, threadID, "")
+	}
+}
+
+func handleAlertAdd(ctx context.Context, b *bot.Bot, update *models.Update, store *alert.Store) {
+	chatID, threadID := update.Message.Chat.ID, update.Message.MessageThreadID
+
+	fields, opts := parseRateFlags(strings.Fields(update.Message.Text))
+	// fields: /alert add <银行> <币种> <条件...>
+	if len(fields) < 5 {
+		tools.SendMessage(ctx, b, chatID, alertUsage, threadID, "")
+		return
+	}
+	p, ccy, ok := alertTarget(ctx, b, update, fields, 2, 3)
+	if !ok {
+		return
+	}
+	m := alertCond.FindStringSubmatch(strings.Join(fields[4:], ""))
+	if m == nil {
+		tools.SendMessage(ctx, b, chatID, fmt.Sprintf("条件格式不正确，例如: &lt; 4.70\n\n%s", alertUsage), threadID, "")
+		return
+	}
+	threshold, ok := ParseAmount(m[2])
+	if !ok || !threshold.IsPositive() {
+		tools.SendMessage(ctx, b, chatID, "价格格式不正确，请输入正数，例如: 4.70", threadID, "")
+		return
+	}
+	op := alert.Op(m[1][:1])
+	field := alertField(opts, op)
+
+	cur, ok := currentPrice(ctx, b, update, p, ccy, field)
+	if !ok {
+		return
+	}
+	a := &alert.Alert{Bank: p.Key(), Code: ccy, Field: field, Op: op, Threshold: threshold}
+	if !saveAlert(ctx, b, update, store, a) {
+		return
+	}
+	note := ""
+	if a.Hit(cur) {
+		note = "\n当前价格已满足条件，下一轮检查时就会提醒。"
+	}
+	tools.SendMessage(ctx, b, chatID, fmt.Sprintf("已添加提醒 #%d: %s\n当前%s: %s%s",
+		a.ID, html.EscapeString(describeAlert(a)), field.Label(), formatPrice(cur), note), threadID, "HTML")
+}
+
+// handleAlertMove /alert move <银行> <币种> [选项] <幅度%> [基准价]
+func handleAlertMove(ctx context.Context, b *bot.Bot, update *models.Update, store *alert.Store) {
+	chatID, threadID := update.Message.Chat.ID, update.Message.MessageThreadID
+
+	fields, opts := parseRateFlags(strings.Fields(update.Message.Text))
+	if len(fields) < 5 {
+		tools.SendMessage(ctx, b, chatID, alertUsage, threadID, "")
+		return
+	}
+	p, ccy, ok := alertTarget(ctx, b, update, fields, 2, 3)
+	if !ok {
+		return
+	}
+	pct, ok := ParseAmount(strings.TrimRight(fields[4], "%％"))
+	if !ok || !pct.IsPositive() || pct.GreaterThan(decimal.NewFromInt(50)) {
+		tools.SendMessage(ctx, b, chatID, "幅度格式不正确，请输入 0 到 50 之间的百分比，例如: 0.3%", threadID, "")
+		return
+	}
+	field := chartField(opts)
+	cur, ok := currentPrice(ctx, b, update, p, ccy, field)
+	if !ok {
+		return
+	}
+	base := cur
+	if len(fields) >= 6 {
+		v, ok := ParseAmount(fields[5])
+		if !ok || !v.IsPositive() {
+			tools.SendMessage(ctx, b, chatID, "基准价格式不正确，请输入正数，例如: 710.5", threadID, "")
+			return
+		}
+		base = v
+	}
+
+	a := &alert.Alert{Kind: alert.Move, Bank: p.Key(), Code: ccy, Field: field, Threshold: pct, Baseline: base}
+	if !saveAlert(ctx, b, update, store, a) {
+		return
+	}
+	tools.SendMessage(ctx, b, chatID, fmt.Sprintf("已添加提醒 #%d: %s\n当前%s: %s\n每次触发后以触发时的价格作为新基准。",
+		a.ID, html.EscapeString(describeAlert(a)), field.Label(), formatPrice(cur)), threadID, "HTML")
+}
+
+// handleAlertBest /alert best <币种> [选项] [N天] [银行]
+func handleAlertBest(ctx context.Context, b *bot.Bot, update *models.Update, store *alert.Store) {
+	chatID, threadID := update.Message.Chat.ID, update.Message.MessageThreadID
+
+	if history.Default() == nil {
+		tools.SendMessage(ctx, b, chatID, "最优提醒需要牌价历史记录，当前未启用。", threadID, "")
+		return
+	}
+	fields, opts := parseRateFlags(strings.Fields(update.Message.Text))
+	if len(fields) < 3 {
+		tools.SendMessage(ctx, b, chatID, alertUsage, threadID, "")
+		return
+	}
+	if !resolveArgs(ctx, b, update, fields, 2) {
+		return
+	}
+	ccy := fields[2]
+	if currency.IsCNY(ccy) {
+		tools.SendMessage(ctx, b, chatID, alertUsage, threadID, "")
+		return
+	}
+	if opts.Side == bank.MidSide {
+		tools.SendMessage(ctx, b, chatID, "最优提醒只支持买入价或卖出价。", threadID, "")
+		return
+	}
+
+	a := &alert.Alert{Kind: alert.Best, Code: ccy, Field: chartField(opts), Days: 30}
+	for _, arg := range fields[3:] {
+		if p, ok := bank.Lookup(arg); ok {
+			a.Bank = p.Key()
+			continue
+		}
+		d, err := parseSpan(arg)
+		if err != nil || d < 24*time.Hour {
+			tools.SendMessage(ctx, b, chatID, fmt.Sprintf("无法识别「%s」，回看天数形如 30d，银行形如 boc。", html.EscapeString(arg)), threadID, "")
+			return
+		}
+		a.Days = int(d / (24 * time.Hour))
+	}
+	if !saveAlert(ctx, b, update, store, a) {
+		return
+	}
+	tools.SendMessage(ctx, b, chatID, fmt.Sprintf("已添加提醒 #%d: %s\n以已记录的牌价历史为准，记录不足 %d 天时按已有记录比较。",
+		a.ID, html.EscapeString(describeAlert(a)), a.Days), threadID, "HTML")
+}
+
+// handleAlertBeat /alert beat <银行> <对比银行> <币种> [选项] <价差>
+func handleAlertBeat(ctx context.Context, b *bot.Bot, update *models.Update, store *alert.Store) {
+	chatID, threadID := update.Message.Chat.ID, update.Message.MessageThreadID
+
+	fields, opts := parseRateFlags(strings.Fields(update.Message.Text))
+	if len(fields) < 6 {
+		tools.SendMessage(ctx, b, chatID, alertUsage, threadID, "")
+		return
+	}
+	other, ok := bank.Lookup(fields[3])
+	if !ok {
+		tools.SendMessage(ctx, b, chatID, fmt.Sprintf("未知的银行「%s」。\n\n%s", html.EscapeString(fields[3]), alertUsage), threadID, "")
+		return
+	}
+	p, ccy, ok := alertTarget(ctx, b, update, fields, 2, 4)
+	if !ok {
+		return
+	}
+	if p.Key() == other.Key() {
+		tools.SendMessage(ctx, b, chatID, "请指定两家不同的银行。", threadID, "")
+		return
+	}
+	if opts.Side == bank.MidSide {
+		tools.SendMessage(ctx, b, chatID, "价差提醒只支持买入价或卖出价。", threadID, "")
+		return
+	}
+	gap, ok := ParseAmount(fields[5])
+	if !ok || gap.IsNegative() {
+		tools.SendMessage(ctx, b, chatID, "价差格式不正确，请输入每100外币的价差，例如: 0.5", threadID, "")
+		return
+	}
+	field := chartField(opts)
+	cur, ok := currentPrice(ctx, b, update, p, ccy, field)
+	if !ok {
+		return
+	}
+	ref, ok := currentPrice(ctx, b, update, other, ccy, field)
+	if !ok {
+		return
+	}
+
+	a := &alert.Alert{Kind: alert.Beat, Bank: p.Key(), Other: other.Key(), Code: ccy, Field: field, Threshold: gap}
+	if !saveAlert(ctx, b, update, store, a) {
+		return
+	}
+	tools.SendMessage(ctx, b, chatID, fmt.Sprintf("已添加提醒 #%d: %s\n当前 %s %s，%s %s",
+		a.ID, html.EscapeString(describeAlert(a)),
+		html.EscapeString(p.Name()), formatPrice(cur), html.EscapeString(other.Name()), formatPrice(ref)), threadID, "HTML")
+}
+
+// alertTarget 解析 fields[bi] 的银行与 fields[ci] 的币种；失败时已回复用户
+func alertTarget(ctx context.Context, b *bot.Bot, update *models.Update, fields []string, bi, ci int) (bank.Provider, string, bool) {
+	chatID, threadID := update.Message.Chat.ID, update.Message.MessageThreadID
+	p, ok := bank.Lookup(fields[bi])
+	if !ok {
+		tools.SendMessage(ctx, b, chatID, fmt.Sprintf("未知的银行「%s」。\n\n%s", html.EscapeString(fields[bi]), alertUsage), threadID, "")
+		return nil, "", false
+	}
+	if !resolveArgs(ctx, b, update, fields, ci) {
+		return nil, "", false
+	}
+	if currency.IsCNY(fields[ci]) {
+		tools.SendMessage(ctx, b, chatID, alertUsage, threadID, "")
+		return nil, "", false
+	}
+	return p, fields[ci], true
+}
+
+// currentPrice 取 p 的 ccy 当前某项牌价（每100外币）；取不到时已回复用户
+func currentPrice(ctx context.Context, b *bot.Bot, update *models.Update, p bank.Provider, ccy string, field bank.Field) (decimal.Decimal, bool) {
+	chatID, threadID := update.Message.Chat.ID, update.Message.MessageThreadID
+	q, found, err := bank.FetchQuote(ctx, p, ccy)
+	if err != nil {
+		tools.LogError("alert: %s 获取失败: %v", p.Name(), err)
+		tools.SendMessage(ctx, b, chatID, "查询失败，请稍后再试。", threadID, "")
+		return decimal.Zero, false
+	}
+	if !found {
+		tools.SendMessage(ctx, b, chatID, fmt.Sprintf("%s没有 %s 的牌价。", p.Name(), ccy), threadID, "")
+		return decimal.Zero, false
+	}
+	v := q.Per(field, 100)
+	if !v.Valid {
+		tools.SendMessage(ctx, b, chatID, fmt.Sprintf("%s的 %s 没有%s。", p.Name(), ccy, field.Label()), threadID, "")
+		return decimal.Zero, false
+	}
+	return v.Decimal, true
+}
+
+// saveAlert 补上会话与用户后保存；失败时已回复用户
+func saveAlert(ctx context.Context, b *bot.Bot, update *models.Update, store *alert.Store, a *alert.Alert) bool {
+	chatID, threadID := update.Message.Chat.ID, update.Message.MessageThreadID
+	a.ChatID, a.ThreadID = chatID, threadID
+	if update.Message.From != nil {
+		a.UserID = update.Message.From.ID
+	}
+	if err := store.Add(a); err != nil {
+		if errors.Is(err, alert.ErrTooMany) {
+			tools.SendMessage(ctx, b, chatID, fmt.Sprintf("本会话的提醒已达上限（%d 条），请先删除一些。", alert.MaxPerChat), threadID, "")
+			return false
+		}
+		tools.LogError("alert: 保存失败: %v", err)
+		tools.SendMessage(ctx, b, chatID, "保存失败，请稍后再试。", threadID, "")
+		return false
+	}
+	return true
+}
+
+func handleAlertList(ctx context.Context, b *bot.Bot, update *models.Update, store *alert.Store) {
+	chatID, threadID := update.Message.Chat.ID, update.Message.MessageThreadID
+	alerts, err := store.ByChat(chatID)
+	if err != nil {
+		tools.LogError("alert: 读取失败: %v", err)
+		tools.SendMessage(ctx, b, chatID, "读取提醒失败，请稍后再试。", threadID, "")
+		return
+	}
+	if len(alerts) == 0 {
+		tools.SendMessage(ctx, b, chatID, "本会话还没有价格提醒。\n\n"+alertUsage, threadID, "")
+		return
+	}
+	var sb strings.Builder
+	sb.WriteString("<b>本会话的价格提醒</b>\n")
+	for i := range alerts {
+		a := &alerts[i]
+		sb.WriteString(fmt.Sprintf("#%d %s%s\n", a.ID, html.EscapeString(describeAlert(a)), alertState(a)))
+	}
+	sb.WriteString("\n删除: /alert del [编号]")
+	tools.SendMessage(ctx, b, chatID, sb.String(), threadID, "HTML")
+}
+
+// AlertNotifier 提醒触发时发往添加提醒的会话（话题）
+func AlertNotifier(b *bot.Bot) alert.Notifier {
+	return func(ctx context.Context, ev *alert.Event) error {
+		a := ev.Alert
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("🔔 <b>价格提醒 #%d</b>\n%s\n", a.ID, html.EscapeString(describeAlert(a))))
+		switch a.Kind {
+		case alert.Move:
+			sb.WriteString(fmt.Sprintf("当前%s: <b>%s</b>（较基准 %s %s%%）\n",
+				a.Field.Label(), formatPrice(ev.Value), formatPrice(ev.Ref), signed(ev.Change(), 3)))
+		case alert.Best:
+			sb.WriteString(fmt.Sprintf("%s 当前%s: <b>%s</b>\n此前最优: %s（%s，%s）\n",
+				html.EscapeString(bankName(ev.Bank)), a.Field.Label(), formatPrice(ev.Value),
+				formatPrice(ev.Ref), html.EscapeString(bankName(ev.RefBank)), bank.FormatTime(ev.RefTime)))
+		case alert.Beat:
+			sb.WriteString(fmt.Sprintf("%s: <b>%s</b>，%s: %s，相差 %s\n",
+				html.EscapeString(bankName(ev.Bank)), formatPrice(ev.Value),
+				html.EscapeString(bankName(ev.RefBank)), formatPrice(ev.Ref), formatPrice(ev.Value.Sub(ev.Ref).Abs())))
+		default:
+			sb.WriteString(fmt.Sprintf("当前%s: <b>%s</b>\n", a.Field.Label(), formatPrice(ev.Value)))
+		}
+		sb.WriteString(fmt.Sprintf("发布时间: %s\n", bank.FormatTime(ev.Quote.ReleaseTime)))
+		switch a.Kind {
+		case alert.Move:
+			sb.WriteString(fmt.Sprintf("已以 %s 作为新基准。", formatPrice(ev.Value)))
+		case alert.Best:
+			sb.WriteString("回落后再创新高（低）才会再次提醒。")
+		case alert.Beat:
+			sb.WriteString(fmt.Sprintf("相差回落到 %s 以下后才会再次提醒。", formatPrice(a.Threshold.Div(decimal.NewFromInt(2)))))
+		default:
+			sb.WriteString(fmt.Sprintf("价格回到 %s 后才会再次提醒。", formatPrice(a.RearmLine())))
+		}
+		_, err := tools.SendMessage(ctx, b, a.ChatID, sb.String(), a.ThreadID, "HTML")
+		// 403（被移出群、被拉黑）与 400（会话或话题已不存在）重试无用，提醒随之删除
+		if errors.Is(err, bot.ErrorForbidden) || errors.Is(err, bot.ErrorBadRequest) {
+			return fmt.Errorf("%w: %w", alert.ErrPermanent, err)
+		}
+		return err
+	}
+}
+
+// describeAlert 一句话描述提醒，如 "中国银行 日元 (JPY) 现汇卖出价 < 4.70"
+func describeAlert(a *alert.Alert) string {
+	subject := fmt.Sprintf("%s %s (%s) %s", bankName(a.Bank), currency.Name(a.Code), a.Code, a.Field.Label())
+	switch a.Kind {
+	case alert.Move:
+		return fmt.Sprintf("%s 相对 %s 涨跌超过 %s%%", subject, formatPrice(a.Baseline), a.Threshold.String())
+	case alert.Best:
+		word := "新低"
+		if alert.Higher(a.Field) {
+			word = "新高"
+		}
+		return fmt.Sprintf("%s 创 %d 天%s", subject, a.Days, word)
+	case alert.Beat:
+		word := "低"
+		if alert.Higher(a.Field) {
+			word = "高"
+		}
+		return fmt.Sprintf("%s 比%s%s超过 %s", subject, bankName(a.Other), word, a.Threshold.String())
+	default:
+		return fmt.Sprintf("%s %s %s", subject, a.Op, a.Threshold.String())
+	}
+}
+
+// alertState 列表里的触发状态
+func alertState(a *alert.Alert) string {
+	if a.Kind == alert.Move {
+		if a.FiredAt.IsZero() {
+			return ""
+		}
+		return fmt.Sprintf("（上次触发于 %s）", bank.FormatTime(a.FiredAt))
+	}
+	if !a.Fired {
+		return ""
+	}
+	switch a.Kind {
+	case alert.Best:
+		return fmt.Sprintf("（已于 %s 触发，回落后重新生效）", bank.FormatTime(a.FiredAt))
+	case alert.Beat:
+		return fmt.Sprintf("（已于 %s 触发，相差回落到 %s 以下后重新生效）", bank.FormatTime(a.FiredAt), formatPrice(a.Threshold.Div(decimal.NewFromInt(2))))
+	default:
+		return fmt.Sprintf("（已于 %s 触发，价格回到 %s 后重新生效）", bank.FormatTime(a.FiredAt), formatPrice(a.RearmLine()))
+	}
+}
+
+// bankName 来源中文名；空 key 表示任一来源
+func bankName(key string) string {
+	if key == "" {
+		return "任一银行"
+	}
+	if p, ok := bank.Lookup(key); ok {
+		return p.Name()
+	}
+	return key
+}
+
+func formatPrice(v decimal.Decimal) string {
+	return boardPrice(decimal.NewNullDecimal(v))
+}
+
+// signed 带正负号的定点小数
+func signed(v decimal.Decimal, places int32) string {
+	if v.IsNegative() {
+		return v.StringFixed(places)
+	}
+	return "+" + v.StringFixed(places)
+}
+
+// alertField 提醒看哪一项牌价；未指定买入/卖出时跌破看卖出价（等着买外币），升破看买入价（等着卖外币）
+func alertField(opts rateOpts, op alert.Op) bank.Field {
+	switch opts.Side {
+	case bank.MidSide:
+		return bank.Middle
+	case bank.BuySide:
+		return bank.SideField(true, opts.Method)
+	case bank.SellSide:
+		return bank.SideField(false, opts.Method)
+	}
+	return bank.SideField(op == alert.Above, opts.Method)
+}
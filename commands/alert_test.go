@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"aki.telegram.bot.fxrate/alert"
+	"aki.telegram.bot.fxrate/bank"
+)
+
+func TestAlertCond(t *testing.T) {
+	tests := []struct {
+		args      []string // 命令中条件部分，按空白切开
+		op        alert.Op
+		threshold string
+	}{
+		{[]string{"<", "4.70"}, alert.Below, "4.70"},
+		{[]string{"<4.70"}, alert.Below, "4.70"},
+		{[]string{"<=", "4.70"}, alert.Below, "4.70"},
+		{[]string{">720"}, alert.Above, "720"},
+		{[]string{">=", "7,200.5"}, alert.Above, "7,200.5"},
+	}
+	for _, tt := range tests {
+		cond := strings.Join(tt.args, "")
+		m := alertCond.FindStringSubmatch(cond)
+		if m == nil {
+			t.Errorf("alertCond(%q) no match", cond)
+			continue
+		}
+		if op := alert.Op(m[1][:1]); op != tt.op || m[2] != tt.threshold {
+			t.Errorf("alertCond(%q) = %s %s, want %s %s", cond, op, m[2], tt.op, tt.threshold)
+		}
+	}
+
+	for _, cond := range []string{"", "4.70", "=4.70", "<>4.70", "<", "<abc", "< 4.70", "4.70<", "<4.70usd"} {
+		if m := alertCond.FindStringSubmatch(cond); m != nil {
+			t.Errorf("alertCond(%q) = %q, want no match", cond, m)
+		}
+	}
+}
+
+func TestAlertField(t *testing.T) {
+	tests := []struct {
+		opts rateOpts
+		op   alert.Op
+		want bank.Field
+	}{
+		{rateOpts{}, alert.Below, bank.SellSpot}, // 等跌破：打算购汇
+		{rateOpts{}, alert.Above, bank.BuySpot},  // 等升破：打算结汇
+		{rateOpts{Method: bank.Cash}, alert.Below, bank.SellCash},
+		{rateOpts{Side: bank.BuySide}, alert.Below, bank.BuySpot},
+		{rateOpts{Side: bank.SellSide, Method: bank.Cash}, alert.Above, bank.SellCash},
+		{rateOpts{Side: bank.MidSide, Method: bank.Cash}, alert.Above, bank.Middle},
+	}
+	for _, tt := range tests {
+		if got := alertField(tt.opts, tt.op); got != tt.want {
+			t.Errorf("alertField(%+v, %s) = %s, want %s", tt.opts, tt.op, got.Label(), tt.want.Label())
+		}
+	}
+}
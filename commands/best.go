@@ -0,0 +1,201 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/shopspring/decimal"
+
+	"aki.telegram.bot.fxrate/bank"
+	"aki.telegram.bot.fxrate/currency"
+	"aki.telegram.bot.fxrate/money"
+	"aki.telegram.bot.fxrate/tools"
+)
+
+// bestRoute 某个来源换算出的结果
+type bestRoute struct {
+	BankNameCN  string
+	BankKey     string
+	Result      decimal.Decimal // 得到的目标币种金额
+	TargetCNY   decimal.Decimal // 该来源下 1 目标币种折合人民币，用于把差额换成人民币
+	Detail      string          // 使用的牌价说明
+	ReleaseTime string
+}
+
+// HandleBestCommand /best <币种> <金额> [目标币种]：在所有来源里找同一笔换汇的最优去处
+func HandleBestCommand(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update == nil || update.Message == nil {
+		return
+	}
+	chatID, threadID := update.Message.Chat.ID, update.Message.MessageThreadID
+
+	fields, opts := parseRateFlags(strings.Fields(update.Message.Text))
+	if len(fields) < 3 {
+		tools.SendMessage(ctx, b, chatID,
+			"用法: /best [币种] [金额] [目标币种]\n"+
+				"示例:\n"+
+				"/best hkd 10000 - 10000HKD 在哪家换成 CNY 最多\n"+
+				"/best cny 10000 usd - 10000CNY 在哪家换到的 USD 最多\n"+
+				"/best usd 1000 hkd - 经人民币换算，1000USD 在哪家换到的 HKD 最多\n"+
+				rateFlagsUsage,
+			threadID, "")
+		return
+	}
+	if !resolveArgs(ctx, b, update, fields, 1, 3) {
+		return
+	}
+	from := fields[1]
+	to := "CNY"
+	if len(fields) >= 4 {
+		to = fields[3]
+	}
+	amount, ok := ParseAmount(fields[2])
+	if !ok || !amount.IsPositive() {
+		tools.SendMessage(ctx, b, chatID, "金额格式不正确，请输入正数，例如: 100 或 100.5", threadID, "")
+		return
+	}
+	if strings.EqualFold(from, to) {
+		tools.SendMessage(ctx, b, chatID, "源币种与目标币种相同，无需换算。", threadID, "")
+		return
+	}
+	sendBest(ctx, b, update, from, to, amount, opts)
+}
+
+// sendBest 比对各银行后发送最优换汇结果
+func sendBest(ctx context.Context, b *bot.Bot, update *models.Update, from, to string, amount decimal.Decimal, opts rateOpts) {
+	chatID, threadID := update.Message.Chat.ID, update.Message.MessageThreadID
+	waitMsgID, _ := tools.SendMessage(ctx, b, chatID,
+		fmt.Sprintf("正在比对各银行 %s %s -> %s 的换算结果，请稍候…", money.Format(amount, from), from, to),
+		threadID, "")
+
+	const timeout = 20 * time.Second
+	results, timeoutKeys := collectBestRoutes(ctx, from, to, amount, opts, timeout)
+	_ = tools.DeleteMessage(ctx, b, chatID, waitMsgID)
+
+	if len(results) == 0 {
+		tools.SendMessage(ctx, b, chatID, fmt.Sprintf("没有银行能提供 %s -> %s 的有效牌价。", from, to), threadID, "")
+		return
+	}
+	tools.SendMessage(ctx, b, chatID, renderBest(from, to, amount, results), threadID, "")
+	if len(timeoutKeys) > 0 {
+		tools.SendMessage(ctx, b, chatID, fmt.Sprintf("提醒：以下银行查询超时（>%s）：%s", timeout, strings.Join(mapBankNames(timeoutKeys), ", ")), threadID, "")
+	}
+}
+
+// collectBestRoutes 并发在所有来源上换算，结果按得到的目标币种金额降序；
+// 返回的 timeoutKeys 为超过 timeout 未返回的来源
+func collectBestRoutes(ctx context.Context, from, to string, amount decimal.Decimal, opts rateOpts, timeout time.Duration) ([]bestRoute, []string) {
+	providers := bank.Providers()
+	resultsCh := make(chan *bestRoute, len(providers))
+	timeoutsCh := make(chan string, len(providers))
+	var wg sync.WaitGroup
+	for _, p := range providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctxFetch, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			r, err := bestRouteOf(ctxFetch, p, from, to, amount, opts)
+			if err != nil && ctxFetch.Err() == context.DeadlineExceeded {
+				timeoutsCh <- p.Key()
+				tools.LogError("best: %s 查询超时（>%s）", p.Name(), timeout)
+				return
+			}
+			if r != nil {
+				resultsCh <- r
+			}
+		}()
+	}
+	wg.Wait()
+	close(resultsCh)
+	close(timeoutsCh)
+
+	var results []bestRoute
+	for r := range resultsCh {
+		results = append(results, *r)
+	}
+	var timeoutKeys []string
+	for k := range timeoutsCh {
+		timeoutKeys = append(timeoutKeys, k)
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Result.GreaterThan(results[j].Result) })
+	return results, timeoutKeys
+}
+
+// renderBest 排名文本，results 须已按 collectBestRoutes 排好序
+func renderBest(from, to string, amount decimal.Decimal, results []bestRoute) string {
+	best := results[0].Result
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("最优换汇 — %s %s -> %s\n\n", money.Format(amount, from), from, to))
+	for i, r := range results {
+		sb.WriteString(fmt.Sprintf("%d. %s: %s %s", i+1, r.BankNameCN, money.Format(r.Result, to), to))
+		if i > 0 {
+			// 少拿到的目标币种按该行自己的牌价折成人民币
+			diff := best.Sub(r.Result).Mul(r.TargetCNY)
+			sb.WriteString(fmt.Sprintf("（少 %s CNY）", money.Format(diff, "CNY")))
+		}
+		sb.WriteString(fmt.Sprintf("\n   %s，发布时间: %s\n", r.Detail, r.ReleaseTime))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// bestRouteOf 按 p 的牌价换算；来源不支持该币种或缺少对应牌价时返回 nil, nil
+func bestRouteOf(ctx context.Context, p bank.Provider, from, to string, amount decimal.Decimal, opts rateOpts) (*bestRoute, error) {
+	snap, err := p.Snapshot(ctx)
+	if err != nil {
+		tools.LogError("best: %s 获取失败: %v", p.Name(), err)
+		return nil, err
+	}
+	r := &bestRoute{BankNameCN: p.Name(), BankKey: p.Key(), ReleaseTime: bank.FormatTime(snap.ReleaseTime)}
+
+	if !currency.IsCNY(from) && !currency.IsCNY(to) {
+		src, ok1 := snap.Find(from)
+		dst, ok2 := snap.Find(to)
+		if !ok1 || !ok2 {
+			return nil, nil
+		}
+		x, err := bank.ConvertCross(src, dst, amount, opts.Side, opts.Method, money.HalfUp)
+		if err != nil {
+			return nil, ignoreNoPrice(err)
+		}
+		r.Result = x.Result()
+		r.TargetCNY = dst.Per(x.Target.Field, 1).Decimal
+		r.Detail = fmt.Sprintf("%s %s / %s %s", from, x.Source.Field.Label(), to, x.Target.Field.Label())
+		return r, nil
+	}
+
+	fx := from
+	if currency.IsCNY(from) {
+		fx = to
+	}
+	q, ok := snap.Find(fx)
+	if !ok {
+		return nil, nil
+	}
+	c, err := bank.Convert(q, from, to, amount, opts.Side, opts.Method, money.HalfUp)
+	if err != nil {
+		return nil, ignoreNoPrice(err)
+	}
+	r.Result = c.Result
+	r.TargetCNY = decimal.NewFromInt(1)
+	if !currency.IsCNY(to) {
+		r.TargetCNY = q.Per(c.Field, 1).Decimal
+	}
+	r.Detail = fmt.Sprintf("%s %s", c.Field.Label(), money.FormatRate(q.Per(c.Field, 100).Decimal))
+	return r, nil
+}
+
+// ignoreNoPrice 来源缺少对应牌价（如银联只有参考汇率）不算错误
+func ignoreNoPrice(err error) error {
+	if errors.Is(err, bank.ErrNoPrice) {
+		return nil
+	}
+	return err
+}
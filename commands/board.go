@@ -0,0 +1,192 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/shopspring/decimal"
+
+	"aki.telegram.bot.fxrate/bank"
+	"aki.telegram.bot.fxrate/tools"
+)
+
+// BoardPrefix 牌价总表翻页按钮的回调前缀，格式 "bd:<银行>:<页码>"
+const BoardPrefix = "bd:"
+
+// boardCurrent 中间显示页码的按钮，点了只应答，不重新渲染
+const boardCurrent = BoardPrefix + "-"
+
+// boardPageSize 每页币种数，手机上一屏大致放得下
+const boardPageSize = 12
+
+// boardLabels 表头用的短列名
+var boardLabels = map[bank.Field]string{
+	bank.BuySpot:  "汇买",
+	bank.BuyCash:  "钞买",
+	bank.SellSpot: "汇卖",
+	bank.SellCash: "钞卖",
+	bank.Middle:   "中间",
+}
+
+// handleBoard /<bank> all：发送该来源全部币种牌价的第一页
+func handleBoard(ctx context.Context, b *bot.Bot, update *models.Update, key string) {
+	chatID, threadID := update.Message.Chat.ID, update.Message.MessageThreadID
+	text, markup, err := renderBoard(ctx, key, 0)
+	if err != nil {
+		tools.LogError("board %s: %v", key, err)
+		tools.SendMessage(ctx, b, chatID, "查询失败，请稍后再试。", threadID, "")
+		return
+	}
+	tools.SendMessageWithMarkup(ctx, b, chatID, text, threadID, "HTML", markup)
+}
+
+// HandleBoardCallback 翻页：重新渲染对应页并原地修改消息
+func HandleBoardCallback(ctx context.Context, b *bot.Bot, cq *models.CallbackQuery) {
+	if cq.Data == boardCurrent {
+		// 内容不变时编辑会报 "message is not modified"
+		tools.AnswerCallbackQuery(ctx, b, cq.ID, "")
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(cq.Data, BoardPrefix), ":")
+	msg := cq.Message.Message
+	if len(parts) != 2 || msg == nil {
+		tools.AnswerCallbackQuery(ctx, b, cq.ID, "按钮已失效，请重新发送命令")
+		return
+	}
+	page, err := strconv.Atoi(parts[1])
+	if err != nil {
+		tools.AnswerCallbackQuery(ctx, b, cq.ID, "按钮已失效，请重新发送命令")
+		return
+	}
+	text, markup, err := renderBoard(ctx, parts[0], page)
+	if err != nil {
+		tools.LogError("board %s: %v", parts[0], err)
+		tools.AnswerCallbackQuery(ctx, b, cq.ID, "查询失败，请稍后再试")
+		return
+	}
+	tools.AnswerCallbackQuery(ctx, b, cq.ID, "")
+	tools.EditMessageText(ctx, b, msg.Chat.ID, msg.ID, text, "HTML", markup)
+}
+
+// renderBoard 渲染第 page 页（从 0 开始，越界时取最近的一页）
+func renderBoard(ctx context.Context, key string, page int) (string, models.ReplyMarkup, error) {
+	p, ok := bank.Lookup(key)
+	if !ok {
+		return "", nil, fmt.Errorf("unknown provider %q", key)
+	}
+	snap, err := p.Snapshot(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	pages := (len(snap.Quotes) + boardPageSize - 1) / boardPageSize
+	if pages == 0 {
+		pages = 1
+	}
+	page = min(max(page, 0), pages-1)
+	start := page * boardPageSize
+	end := min(start+boardPageSize, len(snap.Quotes))
+	rows := snap.Quotes[start:end]
+
+	// 整张表都没有的列（如银联只有参考汇率）直接省掉
+	var cols []bank.Field
+	for _, f := range bank.Fields {
+		for i := range snap.Quotes {
+			if snap.Quotes[i].Get(f).Valid {
+				cols = append(cols, f)
+				break
+			}
+		}
+	}
+
+	table := [][]string{{"代码"}}
+	for _, f := range cols {
+		table[0] = append(table[0], boardLabels[f])
+	}
+	for i := range rows {
+		q := &rows[i]
+		name := q.Code
+		if name == "" {
+			name = q.Name
+		}
+		line := []string{name}
+		for _, f := range cols {
+			line = append(line, boardPrice(q.Per(f, 100)))
+		}
+		table = append(table, line)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("<b>%s 全部牌价</b>（每100外币）\n", html.EscapeString(p.Name())))
+	sb.WriteString("<pre>")
+	sb.WriteString(html.EscapeString(alignTable(table)))
+	sb.WriteString("</pre>\n")
+	sb.WriteString(fmt.Sprintf("发布时间: %s", bank.FormatTime(snap.ReleaseTime)))
+
+	if pages == 1 {
+		return sb.String(), nil, nil
+	}
+	var nav []models.InlineKeyboardButton
+	if page > 0 {
+		nav = append(nav, models.InlineKeyboardButton{Text: "◀ 上一页", CallbackData: fmt.Sprintf("%s%s:%d", BoardPrefix, key, page-1)})
+	}
+	nav = append(nav, models.InlineKeyboardButton{Text: fmt.Sprintf("%d/%d", page+1, pages), CallbackData: boardCurrent})
+	if page < pages-1 {
+		nav = append(nav, models.InlineKeyboardButton{Text: "下一页 ▶", CallbackData: fmt.Sprintf("%s%s:%d", BoardPrefix, key, page+1)})
+	}
+	return sb.String(), &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{nav}}, nil
+}
+
+// boardPrice 表格里的价格：百位以上保留 2 位小数，否则 4 位，缺失为 "-"
+func boardPrice(v decimal.NullDecimal) string {
+	if !v.Valid {
+		return "-"
+	}
+	if v.Decimal.GreaterThanOrEqual(decimal.NewFromInt(100)) {
+		return v.Decimal.StringFixed(2)
+	}
+	return v.Decimal.StringFixed(4)
+}
+
+// alignTable 按等宽字体对齐，首列左对齐、其余右对齐；中文按两个字符宽计算
+func alignTable(rows [][]string) string {
+	var widths []int
+	for _, r := range rows {
+		for i, c := range r {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], displayWidth(c))
+		}
+	}
+	var sb strings.Builder
+	for _, r := range rows {
+		for i, c := range r {
+			pad := strings.Repeat(" ", widths[i]-displayWidth(c))
+			if i == 0 {
+				sb.WriteString(c + pad)
+			} else {
+				sb.WriteString(" " + pad + c)
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func displayWidth(s string) int {
+	w := 0
+	for _, r := range s {
+		if r >= 0x2E80 {
+			w += 2
+		} else {
+			w++
+		}
+	}
+	return w
+}
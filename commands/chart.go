@@ -0,0 +1,151 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/shopspring/decimal"
+
+	"aki.telegram.bot.fxrate/bank"
+	"aki.telegram.bot.fxrate/chart"
+	"aki.telegram.bot.fxrate/currency"
+	"aki.telegram.bot.fxrate/history"
+	"aki.telegram.bot.fxrate/tools"
+)
+
+const chartUsage = "用法: /chart [币种] [银行,银行...] [时间范围] [buy/sell/mid] [spot/cash]\n" +
+	"银行默认全部，时间范围同 /history（默认 7d），默认画现汇卖出价\n" +
+	"示例:\n" +
+	"/chart usd\n" +
+	"/chart jpy boc,cmb 30d buy\n" +
+	"/chart hkd cib 2026-01-01 2026-01-31 cash"
+
+// HandleChartCommand /chart <币种> [银行...] [范围] [选项]：把历史牌价画成折线图，以图片发送
+func HandleChartCommand(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update == nil || update.Message == nil {
+		return
+	}
+	chatID, threadID := update.Message.Chat.ID, update.Message.MessageThreadID
+
+	store := history.Default()
+	if store == nil {
+		tools.SendMessage(ctx, b, chatID, "未启用牌价历史记录。", threadID, "")
+		return
+	}
+
+	fields, opts := parseRateFlags(strings.Fields(update.Message.Text))
+	if len(fields) < 2 {
+		tools.SendMessage(ctx, b, chatID, chartUsage, threadID, "")
+		return
+	}
+	if !resolveArgs(ctx, b, update, fields, 1) {
+		return
+	}
+	ccy := fields[1]
+	if currency.IsCNY(ccy) {
+		tools.SendMessage(ctx, b, chatID, chartUsage, threadID, "")
+		return
+	}
+
+	providers, windowArgs := splitChartArgs(fields[2:])
+	if len(providers) == 0 {
+		providers = bank.Providers()
+	}
+	from, to, err := parseWindow(windowArgs, time.Now())
+	if err != nil {
+		tools.SendMessage(ctx, b, chatID, fmt.Sprintf("%v\n\n%s", err, chartUsage), threadID, "")
+		return
+	}
+	field := chartField(opts)
+
+	var (
+		series  []chart.Series
+		caption strings.Builder
+	)
+	caption.WriteString(fmt.Sprintf("<b>%s (%s) %s走势</b>（每100外币）\n%s ~ %s\n",
+		html.EscapeString(currency.Name(ccy)), ccy, field.Label(), formatDay(from), formatDay(to.Add(-time.Second))))
+	for _, p := range providers {
+		records, err := store.Range(p.Key(), ccy, from, to)
+		if err != nil {
+			tools.LogError("chart: %s %s 读取失败: %v", p.Key(), ccy, err)
+			continue
+		}
+		points := history.Series(records, field)
+		if len(points) == 0 {
+			continue
+		}
+		s := chart.Series{Label: strings.ToUpper(p.Key())}
+		lo, hi := points[0].Value, points[0].Value
+		for _, pt := range points {
+			s.Points = append(s.Points, chart.Point{Time: pt.Time.In(bank.Shanghai), Value: pt.Value.InexactFloat64()})
+			lo, hi = decimal.Min(lo, pt.Value), decimal.Max(hi, pt.Value)
+		}
+		series = append(series, s)
+		caption.WriteString(fmt.Sprintf("%s %s: 最低 %s 最高 %s 最新 %s\n",
+			s.Label, html.EscapeString(p.Name()),
+			boardPrice(decimal.NewNullDecimal(lo)), boardPrice(decimal.NewNullDecimal(hi)),
+			boardPrice(decimal.NewNullDecimal(points[len(points)-1].Value))))
+	}
+	if len(series) == 0 {
+		tools.SendMessage(ctx, b, chatID, fmt.Sprintf("%s 在 %s ~ %s 没有%s的历史记录。",
+			ccy, formatDay(from), formatDay(to.Add(-time.Second)), field.Label()), threadID, "")
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := chart.Line(&buf, series, from.In(bank.Shanghai), to.In(bank.Shanghai)); err != nil {
+		tools.LogError("chart: %s 绘制失败: %v", ccy, err)
+		tools.SendMessage(ctx, b, chatID, "绘图失败，请稍后再试。", threadID, "")
+		return
+	}
+	tools.SendPhoto(ctx, b, chatID, threadID, fmt.Sprintf("%s.png", strings.ToLower(ccy)), buf.Bytes(),
+		strings.TrimRight(caption.String(), "\n"), "HTML")
+}
+
+// splitChartArgs 区分来源参数（可用逗号分隔多个）与时间范围参数
+func splitChartArgs(args []string) (providers []bank.Provider, rest []string) {
+	seen := make(map[string]bool)
+	for _, a := range args {
+		var found []bank.Provider
+		for _, k := range strings.Split(a, ",") {
+			if k == "" {
+				continue
+			}
+			p, ok := bank.Lookup(k)
+			if !ok {
+				found = nil
+				break
+			}
+			found = append(found, p)
+		}
+		if len(found) == 0 {
+			rest = append(rest, a)
+			continue
+		}
+		for _, p := range found {
+			if !seen[p.Key()] {
+				seen[p.Key()] = true
+				providers = append(providers, p)
+			}
+		}
+	}
+	return providers, rest
+}
+
+// chartField 图表画哪一项牌价：默认卖出价，mid 为中间价
+func chartField(opts rateOpts) bank.Field {
+	switch opts.Side {
+	case bank.MidSide:
+		return bank.Middle
+	case bank.BuySide:
+		return bank.SideField(true, opts.Method)
+	default:
+		return bank.SideField(false, opts.Method)
+	}
+}
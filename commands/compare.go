@@ -0,0 +1,155 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/shopspring/decimal"
+
+	"aki.telegram.bot.fxrate/bank"
+	"aki.telegram.bot.fxrate/currency"
+	"aki.telegram.bot.fxrate/tools"
+)
+
+// providerQuote 某个来源的一行牌价
+type providerQuote struct {
+	Provider bank.Provider
+	Quote    *bank.Quote
+}
+
+// fetchAllQuotes 并发从所有来源取 ccy 的牌价，结果按注册顺序排列；
+// 返回的 timeoutKeys 为超过 20s 未返回的来源
+func fetchAllQuotes(ctx context.Context, ccy, tag string) (rows []providerQuote, timeoutKeys []string) {
+	providers := bank.Providers()
+	quotes := make([]*bank.Quote, len(providers))
+	timedOut := make([]bool, len(providers))
+	var wg sync.WaitGroup
+	for i, p := range providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctxFetch, cancel := context.WithTimeout(ctx, 20*time.Second)
+			defer cancel()
+			q, ok, err := bank.FetchQuote(ctxFetch, p, ccy)
+			if err != nil {
+				timedOut[i] = ctxFetch.Err() == context.DeadlineExceeded
+				tools.LogError("%s: %s 获取失败: %v", tag, p.Name(), err)
+				return
+			}
+			if ok {
+				quotes[i] = q
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i, p := range providers {
+		if quotes[i] != nil {
+			rows = append(rows, providerQuote{Provider: p, Quote: quotes[i]})
+		}
+		if timedOut[i] {
+			timeoutKeys = append(timeoutKeys, p.Key())
+		}
+	}
+	return rows, timeoutKeys
+}
+
+// HandleCompareCommand /compare [币种]：各来源同一币种的全部牌价矩阵，每列最优值加 * 标出
+func HandleCompareCommand(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update == nil || update.Message == nil {
+		return
+	}
+	chatID, threadID := update.Message.Chat.ID, update.Message.MessageThreadID
+
+	fields := strings.Fields(update.Message.Text)
+	if len(fields) < 2 {
+		tools.SendMessage(ctx, b, chatID, "用法: /compare [币种]，例如:\n/compare usd", threadID, "")
+		return
+	}
+	if !resolveArgs(ctx, b, update, fields, 1) {
+		return
+	}
+	ccy := fields[1]
+
+	rows, timeoutKeys := fetchAllQuotes(ctx, ccy, "compare")
+	if len(rows) == 0 {
+		tools.SendMessage(ctx, b, chatID, "没有银行提供该币种的牌价。", threadID, "")
+		return
+	}
+
+	tools.SendMessage(ctx, b, chatID, renderCompare(ccy, rows), threadID, "HTML")
+	if len(timeoutKeys) > 0 {
+		tools.SendMessage(ctx, b, chatID, fmt.Sprintf("提醒：以下银行查询超时（>20s）：%s", strings.Join(mapBankNames(timeoutKeys), ", ")), threadID, "")
+	}
+}
+
+// renderCompare 渲染牌价矩阵；买入价越高越优，卖出价越低越优，中间价只作参考不比较。
+// only 非空时只列出其中的项
+func renderCompare(ccy string, rows []providerQuote, only ...bank.Field) string {
+	best := make(map[bank.Field]decimal.Decimal)
+	for _, f := range bank.Fields {
+		if f == bank.Middle {
+			continue
+		}
+		higher := f == bank.BuySpot || f == bank.BuyCash
+		for _, r := range rows {
+			v := r.Quote.Per(f, 100)
+			if !v.Valid {
+				continue
+			}
+			cur, ok := best[f]
+			if !ok || (higher && v.Decimal.GreaterThan(cur)) || (!higher && v.Decimal.LessThan(cur)) {
+				best[f] = v.Decimal
+			}
+		}
+	}
+
+	// 所有银行都没有的列省掉
+	var cols []bank.Field
+	for _, f := range bank.Fields {
+		if len(only) > 0 && !slices.Contains(only, f) {
+			continue
+		}
+		for _, r := range rows {
+			if r.Quote.Get(f).Valid {
+				cols = append(cols, f)
+				break
+			}
+		}
+	}
+
+	table := [][]string{{"银行"}}
+	for _, f := range cols {
+		table[0] = append(table[0], boardLabels[f]+" ")
+	}
+	for _, r := range rows {
+		line := []string{r.Provider.Name()}
+		for _, f := range cols {
+			v := r.Quote.Per(f, 100)
+			mark := " "
+			if bv, ok := best[f]; ok && v.Valid && v.Decimal.Equal(bv) {
+				mark = "*"
+			}
+			line = append(line, boardPrice(v)+mark)
+		}
+		table = append(table, line)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("<b>各银行牌价对比 — %s (%s)</b>（每100外币）\n", html.EscapeString(currency.Name(ccy)), html.EscapeString(ccy)))
+	sb.WriteString("<pre>")
+	sb.WriteString(html.EscapeString(alignTable(table)))
+	sb.WriteString("</pre>\n")
+	sb.WriteString("* 为该列最优：买入价取最高，卖出价取最低\n")
+	for _, r := range rows {
+		sb.WriteString(fmt.Sprintf("%s 发布时间: %s\n", html.EscapeString(r.Provider.Name()), bank.FormatTime(r.Quote.ReleaseTime)))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -0,0 +1,159 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/shopspring/decimal"
+
+	"aki.telegram.bot.fxrate/bank"
+	"aki.telegram.bot.fxrate/currency"
+	"aki.telegram.bot.fxrate/money"
+	"aki.telegram.bot.fxrate/tools"
+)
+
+// handleConvert 各银行命令共用的换算：
+// 默认外币 -> CNY 按买入价（结汇），CNY -> 外币 按卖出价（购汇），opts 可改用现钞、指定买卖方向或中间价；
+// 缺价回落时在消息里注明原因。展示的牌价统一折算为“每100外币”。
+func handleConvert(ctx context.Context, b *bot.Bot, update *models.Update, key, from, to string, amount decimal.Decimal, opts rateOpts) {
+	chatID, threadID := update.Message.Chat.ID, update.Message.MessageThreadID
+	if amount.IsNegative() {
+		tools.SendMessage(ctx, b, chatID, "金额不能为负数。", threadID, "")
+		return
+	}
+	p, ok := bank.Lookup(key)
+	if !ok {
+		tools.LogError("convert: unknown provider %q", key)
+		return
+	}
+
+	fromCode := currency.Normalize(from)
+	toCode := currency.Normalize(to)
+
+	if strings.EqualFold(fromCode, toCode) {
+		msg := fmt.Sprintf("%s %s = %s %s (同币种，无需换算)", money.Format(amount, fromCode), fromCode, money.Format(amount, toCode), toCode)
+		tools.SendMessage(ctx, b, chatID, msg, threadID, "")
+		return
+	}
+
+	toCNY := currency.IsCNY(toCode)
+	fx, role := fromCode, "源"
+	switch {
+	case toCNY && !currency.IsCNY(fromCode):
+	case currency.IsCNY(fromCode):
+		fx, role = toCode, "目标"
+	default:
+		handleCrossConvert(ctx, b, update, p, fromCode, toCode, amount, opts)
+		return
+	}
+
+	q, found, err := bank.FetchQuote(ctx, p, fx)
+	if err != nil {
+		tools.LogError("%s fetch error: %v", p.Name(), err)
+		tools.SendMessage(ctx, b, chatID, "查询失败，请稍后再试。", threadID, "")
+		return
+	}
+	if !found || q == nil {
+		tools.SendMessage(ctx, b, chatID, fmt.Sprintf("未找到该币种，请检查输入的%s币种代码。", role), threadID, "")
+		return
+	}
+
+	c, err := bank.Convert(q, fromCode, toCode, amount, opts.Side, opts.Method, money.HalfUp)
+	if errors.Is(err, bank.ErrNoPrice) {
+		tools.SendMessage(ctx, b, chatID, fmt.Sprintf("%s币种缺少有效的%s，无法换算。", role, sideDesc(opts.Side, toCNY)), threadID, "")
+		return
+	}
+	if err != nil {
+		tools.LogError("%s convert error: %v", p.Name(), err)
+		tools.SendMessage(ctx, b, chatID, "暂不支持~", threadID, "")
+		return
+	}
+
+	rateDisp := money.FormatRate(q.Per(c.Field, 100).Decimal)
+	releaseTime := bank.FormatTime(q.ReleaseTime)
+	var msg string
+	if toCNY {
+		msg = FormatFXToCNY(p.Name(), q.Name, fromCode, amount, c.Result, c.Field.Label(), rateDisp, releaseTime)
+	} else {
+		msg = FormatCNYToFX(p.Name(), q.Name, toCode, amount, c.Result, c.Field.Label(), rateDisp, releaseTime)
+	}
+	msg += fallbackNote(c)
+	tools.SendMessage(ctx, b, chatID, msg, threadID, "")
+}
+
+// handleCrossConvert 外币 -> 外币：源币种按买入价结汇成人民币，再按目标币种卖出价购汇
+func handleCrossConvert(ctx context.Context, b *bot.Bot, update *models.Update, p bank.Provider, fromCode, toCode string, amount decimal.Decimal, opts rateOpts) {
+	chatID, threadID := update.Message.Chat.ID, update.Message.MessageThreadID
+
+	snap, err := p.Snapshot(ctx)
+	if err != nil {
+		tools.LogError("%s fetch error: %v", p.Name(), err)
+		tools.SendMessage(ctx, b, chatID, "查询失败，请稍后再试。", threadID, "")
+		return
+	}
+	src, ok := snap.Find(fromCode)
+	if !ok {
+		tools.SendMessage(ctx, b, chatID, "未找到该币种，请检查输入的源币种代码。", threadID, "")
+		return
+	}
+	dst, ok := snap.Find(toCode)
+	if !ok {
+		tools.SendMessage(ctx, b, chatID, "未找到该币种，请检查输入的目标币种代码。", threadID, "")
+		return
+	}
+
+	x, err := bank.ConvertCross(src, dst, amount, opts.Side, opts.Method, money.HalfUp)
+	if errors.Is(err, bank.ErrNoPrice) {
+		tools.SendMessage(ctx, b, chatID, fmt.Sprintf("%s或%s缺少有效的牌价，无法经人民币换算。", src.Name, dst.Name), threadID, "")
+		return
+	}
+	if err != nil {
+		tools.LogError("%s cross convert error: %v", p.Name(), err)
+		tools.SendMessage(ctx, b, chatID, "暂不支持~", threadID, "")
+		return
+	}
+
+	leg1, leg2 := x.Source, x.Target
+	msg := fmt.Sprintf(
+		"按%s牌价换算: %s -> %s（经人民币）\n\n"+
+			"%s %s ≈ %s %s\n"+
+			"交叉汇率: 1 %s = %s %s\n\n"+
+			"① %s %s -> %s CNY（%s %s）\n"+
+			"② %s CNY -> %s %s（%s %s）\n"+
+			"发布时间: %s",
+		p.Name(), src.Name, dst.Name,
+		money.Format(amount, fromCode), fromCode, money.Format(x.Result(), toCode), toCode,
+		fromCode, x.Rate.String(), toCode,
+		money.Format(amount, fromCode), fromCode, money.Format(leg1.Result, "CNY"),
+		leg1.Field.Label(), money.FormatRate(src.Per(leg1.Field, 100).Decimal),
+		money.Format(leg1.Result, "CNY"), money.Format(leg2.Result, toCode), toCode,
+		leg2.Field.Label(), money.FormatRate(dst.Per(leg2.Field, 100).Decimal),
+		bank.FormatTime(snap.ReleaseTime),
+	)
+	msg += fallbackNote(leg1) + fallbackNote(leg2)
+	tools.SendMessage(ctx, b, chatID, msg, threadID, "")
+}
+
+// fallbackNote 缺价回落时附在消息末尾的说明
+func fallbackNote(c *bank.Conversion) string {
+	if !c.Fallback {
+		return ""
+	}
+	return fmt.Sprintf("\n说明: %s %s无报价，已改用%s", c.Quote.Code, c.Wanted.Label(), c.Field.Label())
+}
+
+// sideDesc 缺价提示里的牌价描述
+func sideDesc(side bank.Side, toCNY bool) string {
+	switch {
+	case side == bank.MidSide:
+		return "中间价"
+	case side == bank.BuySide || (side == bank.AutoSide && toCNY):
+		return "买入价（现汇/现钞）"
+	default:
+		return "卖出价（现汇/现钞）"
+	}
+}
@@ -0,0 +1,304 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/shopspring/decimal"
+
+	"aki.telegram.bot.fxrate/bank"
+	"aki.telegram.bot.fxrate/currency"
+	"aki.telegram.bot.fxrate/digest"
+	"aki.telegram.bot.fxrate/history"
+	"aki.telegram.bot.fxrate/tools"
+)
+
+const digestUsage = "用法:\n" +
+	"/digest add [时间] [币种,币种...] [everyday] - 每个工作日（加 everyday 为每天）定时发送汇总到本会话（话题）\n" +
+	"/digest list - 查看本会话的订阅\n" +
+	"/digest del [编号|all] - 删除自己添加的订阅\n" +
+	"/digest now [币种,币种...] - 立即发送一次汇总\n" +
+	"示例:\n" +
+	"/digest add 09:30 usd,jpy,hkd\n" +
+	"/digest add 17:00 eur gbp everyday\n" +
+	"时间为北京时间；汇总包含各币种最优买入/卖出银行与较前一日的变动"
+
+// digestEveryday 每天发送（含周末与节假日）的关键字
+var digestEveryday = map[string]bool{"everyday": true, "daily": true, "每天": true}
+
+// HandleDigestCommand /digest add|list|del|now：定时汇总订阅管理
+func HandleDigestCommand(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update == nil || update.Message == nil {
+		return
+	}
+	chatID, threadID := update.Message.Chat.ID, update.Message.MessageThreadID
+
+	fields := strings.Fields(update.Message.Text)
+	sub := "list"
+	if len(fields) >= 2 {
+		sub = strings.ToLower(fields[1])
+	}
+	if sub == "now" {
+		handleDigestNow(ctx, b, update, fields)
+		return
+	}
+
+	store := digest.Default()
+	if store == nil {
+		tools.SendMessage(ctx, b, chatID, "未启用定时汇总（数据库不可用）。", threadID, "")
+		return
+	}
+	switch sub {
+	case "add", "new", "添加":
+		handleDigestAdd(ctx, b, update, store, fields)
+	case "list", "ls", "列表":
+		handleDigestList(ctx, b, update, store)
+	case "del", "rm", "delete", "删除":
+		deleteOwned(ctx, b, update, store, ownedKind{Tag: "digest", Noun: "订阅", Command: "/digest"},
+			func(s *digest.Subscription) (uint64, int64, int64) { return s.ID, s.ChatID, s.UserID }, fields[2:])
+	default:
+		tools.SendMessage(ctx, b, chatID, digestUsage, threadID, "")
+	}
+}
+
+func handleDigestAdd(ctx context.Context, b *bot.Bot, update *models.Update, store *digest.Store, fields []string) {
+	chatID, threadID := update.Message.Chat.ID, update.Message.MessageThreadID
+	if len(fields) < 4 {
+		tools.SendMessage(ctx, b, chatID, digestUsage, threadID, "")
+		return
+	}
+	minute, ok := parseClock(fields[2])
+	if !ok {
+		tools.SendMessage(ctx, b, chatID, fmt.Sprintf("时间「%s」格式不正确，例如: 09:30", html.EscapeString(fields[2])), threadID, "")
+		return
+	}
+	s := &digest.Subscription{ChatID: chatID, ThreadID: threadID, Minute: minute}
+	if update.Message.From != nil {
+		s.UserID = update.Message.From.ID
+	}
+	var rest, flags []string
+	for _, f := range fields[3:] {
+		if digestEveryday[strings.ToLower(f)] {
+			s.Everyday = true
+			flags = append(flags, f)
+			continue
+		}
+		rest = append(rest, f)
+	}
+	codes, ok := digestCurrencies(ctx, b, update, fields[:3], rest, flags)
+	if !ok {
+		return
+	}
+	s.Currencies = codes
+
+	if err := store.Add(s); err != nil {
+		if errors.Is(err, digest.ErrTooMany) {
+			tools.SendMessage(ctx, b, chatID, fmt.Sprintf("本会话的订阅已达上限（%d 条），请先删除一些。", digest.MaxPerChat), threadID, "")
+			return
+		}
+		tools.LogError("digest: 保存失败: %v", err)
+		tools.SendMessage(ctx, b, chatID, "保存失败，请稍后再试。", threadID, "")
+		return
+	}
+	tools.SendMessage(ctx, b, chatID, fmt.Sprintf("已添加订阅 #%d: %s", s.ID, describeDigest(s)), threadID, "")
+}
+
+func handleDigestList(ctx context.Context, b *bot.Bot, update *models.Update, store *digest.Store) {
+	chatID, threadID := update.Message.Chat.ID, update.Message.MessageThreadID
+	subs, err := store.ByChat(chatID)
+	if err != nil {
+		tools.LogError("digest: 读取失败: %v", err)
+		tools.SendMessage(ctx, b, chatID, "读取订阅失败，请稍后再试。", threadID, "")
+		return
+	}
+	if len(subs) == 0 {
+		tools.SendMessage(ctx, b, chatID, "本会话还没有定时汇总。\n\n"+digestUsage, threadID, "")
+		return
+	}
+	var sb strings.Builder
+	sb.WriteString("本会话的定时汇总\n")
+	for i := range subs {
+		s := &subs[i]
+		topic := ""
+		if s.ThreadID > 0 {
+			topic = fmt.Sprintf("（话题 %d）", s.ThreadID)
+		}
+		sb.WriteString(fmt.Sprintf("#%d %s%s\n", s.ID, describeDigest(s), topic))
+	}
+	sb.WriteString("\n删除: /digest del [编号]")
+	tools.SendMessage(ctx, b, chatID, sb.String(), threadID, "")
+}
+
+// handleDigestNow /digest now [币种...]：立即发送一次汇总，用于预览
+func handleDigestNow(ctx context.Context, b *bot.Bot, update *models.Update, fields []string) {
+	chatID, threadID := update.Message.Chat.ID, update.Message.MessageThreadID
+	args := fields[2:]
+	if len(args) == 0 {
+		args = []string{"USD,EUR,JPY,HKD"}
+	}
+	codes, ok := digestCurrencies(ctx, b, update, fields[:2], args, nil)
+	if !ok {
+		return
+	}
+	tools.SendMessage(ctx, b, chatID, renderDigest(ctx, codes, time.Now()), threadID, "HTML")
+}
+
+// DigestSender 定时汇总发往订阅所在的会话（话题）
+func DigestSender(b *bot.Bot) digest.Sender {
+	return func(ctx context.Context, s *digest.Subscription) error {
+		_, err := tools.SendMessage(ctx, b, s.ChatID, renderDigest(ctx, s.Currencies, time.Now()), s.ThreadID, "HTML")
+		// 403（被移出群、被拉黑）与 400（会话或话题已不存在）重试无用，当天不再发送
+		if errors.Is(err, bot.ErrorForbidden) || errors.Is(err, bot.ErrorBadRequest) {
+			return fmt.Errorf("%w: %w", digest.ErrPermanent, err)
+		}
+		return err
+	}
+}
+
+// digestCurrencies 把逗号或空格分隔的币种识别为代码并去重；失败时已回复用户。
+// prefix、suffix 为币种前后的其余参数，歧义候选按钮据此拼出完整命令
+func digestCurrencies(ctx context.Context, b *bot.Bot, update *models.Update, prefix, args, suffix []string) ([]string, bool) {
+	chatID, threadID := update.Message.Chat.ID, update.Message.MessageThreadID
+	fields := append([]string(nil), prefix...)
+	var idx []int
+	for _, a := range args {
+		for _, c := range strings.FieldsFunc(a, func(r rune) bool { return r == ',' || r == '，' || r == '、' }) {
+			fields = append(fields, c)
+			idx = append(idx, len(fields)-1)
+		}
+	}
+	if len(idx) == 0 {
+		tools.SendMessage(ctx, b, chatID, "请至少指定一个币种。\n\n"+digestUsage, threadID, "")
+		return nil, false
+	}
+	fields = append(fields, suffix...)
+	if !resolveArgs(ctx, b, update, fields, idx...) {
+		return nil, false
+	}
+	var codes []string
+	seen := make(map[string]bool)
+	for _, i := range idx {
+		c := fields[i]
+		if currency.IsCNY(c) || seen[c] {
+			continue
+		}
+		seen[c] = true
+		codes = append(codes, c)
+	}
+	if len(codes) == 0 {
+		tools.SendMessage(ctx, b, chatID, "请至少指定一个外币。", threadID, "")
+		return nil, false
+	}
+	if len(codes) > digest.MaxCurrencies {
+		tools.SendMessage(ctx, b, chatID, fmt.Sprintf("每个订阅最多 %d 个币种。", digest.MaxCurrencies), threadID, "")
+		return nil, false
+	}
+	return codes, true
+}
+
+// parseClock 解析 "9:30"、"09:30"、"0930"，返回当天第几分钟
+func parseClock(s string) (int, bool) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), "：", ":")
+	for _, layout := range []string{"15:04", "1504"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Hour()*60 + t.Minute(), true
+		}
+	}
+	return 0, false
+}
+
+// describeDigest "工作日 09:30 USD, JPY, HKD"
+func describeDigest(s *digest.Subscription) string {
+	days := "工作日"
+	if s.Everyday {
+		days = "每天"
+	}
+	return fmt.Sprintf("%s %s %s", days, s.Clock(), strings.Join(s.Currencies, ", "))
+}
+
+// renderDigest 汇总：各币种现汇买入最高、卖出最低的银行，以及参考来源现汇卖出价较前一日收盘的变动
+func renderDigest(ctx context.Context, codes []string, now time.Time) string {
+	now = now.In(bank.Shanghai)
+	weekdays := []string{"日", "一", "二", "三", "四", "五", "六"}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("<b>📊 汇率汇总 %s 周%s %s</b>（每100外币）\n",
+		now.Format("2006-01-02"), weekdays[now.Weekday()], now.Format("15:04")))
+
+	for _, ccy := range codes {
+		sb.WriteString(fmt.Sprintf("\n<b>%s (%s)</b>\n", html.EscapeString(currency.Name(ccy)), ccy))
+		rows, _ := fetchAllQuotes(ctx, ccy, "digest")
+		buy, buyBank := bestOf(rows, bank.BuySpot, true)
+		sell, sellBank := bestOf(rows, bank.SellSpot, false)
+		if buyBank == "" && sellBank == "" {
+			sb.WriteString("暂无牌价\n")
+			continue
+		}
+		if buyBank != "" {
+			sb.WriteString(fmt.Sprintf("买入最高: %s %s\n", formatPrice(buy), html.EscapeString(buyBank)))
+		}
+		if sellBank != "" {
+			sb.WriteString(fmt.Sprintf("卖出最低: %s %s\n", formatPrice(sell), html.EscapeString(sellBank)))
+		}
+		if line := dayChange(rows, ccy, now); line != "" {
+			sb.WriteString(line + "\n")
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// bestOf 各来源中某项牌价的最优值与来源名
+func bestOf(rows []providerQuote, f bank.Field, higher bool) (decimal.Decimal, string) {
+	var (
+		best decimal.Decimal
+		name string
+	)
+	for _, r := range rows {
+		v := r.Quote.Per(f, 100)
+		if !v.Valid {
+			continue
+		}
+		if name == "" || (higher && v.Decimal.GreaterThan(best)) || (!higher && v.Decimal.LessThan(best)) {
+			best, name = v.Decimal, r.Provider.Name()
+		}
+	}
+	return best, name
+}
+
+// dayChange 取第一个有历史的来源，比较当前现汇卖出价与前一日最后一次发布
+func dayChange(rows []providerQuote, ccy string, now time.Time) string {
+	store := history.Default()
+	if store == nil {
+		return ""
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, bank.Shanghai)
+	for _, r := range rows {
+		cur := r.Quote.Per(bank.SellSpot, 100)
+		if !cur.Valid {
+			continue
+		}
+		// 往前最多找一周，跨过周末和长假
+		records, err := store.Range(r.Provider.Key(), ccy, today.AddDate(0, 0, -7), today)
+		if err != nil || len(records) == 0 {
+			continue
+		}
+		prev := records[len(records)-1].Quote().Per(bank.SellSpot, 100)
+		if !prev.Valid || prev.Decimal.IsZero() {
+			continue
+		}
+		diff := cur.Decimal.Sub(prev.Decimal)
+		pct := diff.Div(prev.Decimal).Mul(decimal.NewFromInt(100))
+		places := int32(4) // 与 boardPrice 一致：百位以上 2 位小数
+		if prev.Decimal.GreaterThanOrEqual(decimal.NewFromInt(100)) {
+			places = 2
+		}
+		return fmt.Sprintf("%s现汇卖出较 %s: %s（%s%%）", html.EscapeString(r.Provider.Name()),
+			records[len(records)-1].ReleaseTime.In(bank.Shanghai).Format("01-02"), signed(diff, places), signed(pct, 2))
+	}
+	return ""
+}
@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"strings"
+
+	"aki.telegram.bot.fxrate/bank"
+)
+
+// rateOpts 换算时选用哪一项牌价
+type rateOpts struct {
+	Side   bank.Side
+	Method bank.Method
+}
+
+// rateFlags 牌价选项关键字，可出现在命令参数的任意位置
+var rateFlags = map[string]func(*rateOpts){
+	"spot": func(o *rateOpts) { o.Method = bank.Spot },
+	"现汇":   func(o *rateOpts) { o.Method = bank.Spot },
+	"cash": func(o *rateOpts) { o.Method = bank.Cash },
+	"现钞":   func(o *rateOpts) { o.Method = bank.Cash },
+	"buy":  func(o *rateOpts) { o.Side = bank.BuySide },
+	"买入":   func(o *rateOpts) { o.Side = bank.BuySide },
+	"sell": func(o *rateOpts) { o.Side = bank.SellSide },
+	"卖出":   func(o *rateOpts) { o.Side = bank.SellSide },
+	"mid":  func(o *rateOpts) { o.Side = bank.MidSide },
+	"ref":  func(o *rateOpts) { o.Side = bank.MidSide },
+	"中间价":  func(o *rateOpts) { o.Side = bank.MidSide },
+	"折算价":  func(o *rateOpts) { o.Side = bank.MidSide },
+}
+
+// rateFlagsUsage 各银行命令用法里的选项说明
+const rateFlagsUsage = "可选参数: spot/cash 现汇/现钞，buy/sell 买入/卖出价，mid/ref 中间价\n" +
+	"默认外币换 CNY 用现汇买入价，CNY 换外币用现汇卖出价"
+
+// parseRateFlags 从 fields（含命令本身）中取出牌价选项，返回其余参数
+func parseRateFlags(fields []string) ([]string, rateOpts) {
+	var opts rateOpts
+	rest := make([]string, 0, len(fields))
+	for i, f := range fields {
+		if set, ok := rateFlags[strings.ToLower(f)]; ok && i > 0 {
+			set(&opts)
+			continue
+		}
+		rest = append(rest, f)
+	}
+	return rest, opts
+}
@@ -0,0 +1,200 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/shopspring/decimal"
+
+	"aki.telegram.bot.fxrate/bank"
+	"aki.telegram.bot.fxrate/currency"
+	"aki.telegram.bot.fxrate/history"
+	"aki.telegram.bot.fxrate/tools"
+)
+
+// historyMaxDays 最多查询的天数
+const historyMaxDays = 90
+
+// historyMaxRows 日线表最多展示的行数，超出时只展示最近的
+const historyMaxRows = 31
+
+const historyUsage = "用法: /history [银行] [币种] [时间范围]\n" +
+	"时间范围: 7d（默认）、24h、2w，或日期 2026-01-02、2026-01-01 2026-01-07\n" +
+	"示例:\n" +
+	"/history boc usd 7d\n" +
+	"/history cmb hkd 2026-01-01 2026-01-07"
+
+// HandleHistoryCommand /history <银行> <币种> [范围]：某来源某币种的日线开高低收、发布次数与区间最优价
+func HandleHistoryCommand(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update == nil || update.Message == nil {
+		return
+	}
+	chatID, threadID := update.Message.Chat.ID, update.Message.MessageThreadID
+
+	store := history.Default()
+	if store == nil {
+		tools.SendMessage(ctx, b, chatID, "未启用牌价历史记录。", threadID, "")
+		return
+	}
+
+	fields := strings.Fields(update.Message.Text)
+	if len(fields) < 3 {
+		tools.SendMessage(ctx, b, chatID, historyUsage, threadID, "")
+		return
+	}
+	p, ok := bank.Lookup(fields[1])
+	if !ok {
+		tools.SendMessage(ctx, b, chatID, fmt.Sprintf("未知的银行「%s」。\n\n%s", html.EscapeString(fields[1]), historyUsage), threadID, "")
+		return
+	}
+	if !resolveArgs(ctx, b, update, fields, 2) {
+		return
+	}
+	ccy := fields[2]
+
+	from, to, err := parseWindow(fields[3:], time.Now())
+	if err != nil {
+		tools.SendMessage(ctx, b, chatID, fmt.Sprintf("%v\n\n%s", err, historyUsage), threadID, "")
+		return
+	}
+
+	records, err := store.Range(p.Key(), ccy, from, to)
+	if err != nil {
+		tools.LogError("history: %s %s 读取失败: %v", p.Key(), ccy, err)
+		tools.SendMessage(ctx, b, chatID, "读取历史失败，请稍后再试。", threadID, "")
+		return
+	}
+	if len(records) == 0 {
+		tools.SendMessage(ctx, b, chatID, fmt.Sprintf("%s %s 在 %s ~ %s 没有历史记录。", p.Name(), ccy, formatDay(from), formatDay(to.Add(-time.Second))), threadID, "")
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("<b>%s %s (%s) 历史牌价</b>\n%s ~ %s，每100外币\n",
+		html.EscapeString(p.Name()), html.EscapeString(currency.Name(ccy)), ccy,
+		formatDay(from), formatDay(to.Add(-time.Second))))
+	// 两张表按同样的日期分组，天数相同，截断说明只写一次
+	cols := []bank.Field{bank.BuySpot, bank.SellSpot}
+	daily := make([][]history.Bar, len(cols))
+	for i, f := range cols {
+		daily[i] = history.Daily(records, f)
+	}
+	if len(daily[0]) > historyMaxRows {
+		sb.WriteString(fmt.Sprintf("（仅展示最近 %d 天）\n", historyMaxRows))
+	}
+	for i, f := range cols {
+		bars := daily[i]
+		if len(bars) > historyMaxRows {
+			bars = bars[len(bars)-historyMaxRows:]
+		}
+		sb.WriteString(fmt.Sprintf("\n%s\n<pre>%s</pre>\n", f.Label(), html.EscapeString(renderBars(bars))))
+	}
+
+	sb.WriteString("\n")
+	if best, ok := history.Best(history.Series(records, bank.BuySpot), true); ok {
+		sb.WriteString(fmt.Sprintf("最高%s: %s（%s）\n", bank.BuySpot.Label(), boardPrice(decimal.NewNullDecimal(best.Value)), bank.FormatTime(best.Time)))
+	}
+	if best, ok := history.Best(history.Series(records, bank.SellSpot), false); ok {
+		sb.WriteString(fmt.Sprintf("最低%s: %s（%s）\n", bank.SellSpot.Label(), boardPrice(decimal.NewNullDecimal(best.Value)), bank.FormatTime(best.Time)))
+	}
+	sb.WriteString(fmt.Sprintf("区间内共发布 %d 次", len(records)))
+	tools.SendMessage(ctx, b, chatID, sb.String(), threadID, "HTML")
+}
+
+// renderBars 日线表：日期 开 高 低 收 次数
+func renderBars(bars []history.Bar) string {
+	table := [][]string{{"日期", "开", "高", "低", "收", "次数"}}
+	for _, bar := range bars {
+		row := []string{bar.Day.Format("01-02")}
+		if !bar.Valid {
+			row = append(row, "-", "-", "-", "-")
+		} else {
+			for _, v := range []decimal.Decimal{bar.Open, bar.High, bar.Low, bar.Close} {
+				row = append(row, boardPrice(decimal.NewNullDecimal(v)))
+			}
+		}
+		row = append(row, strconv.Itoa(bar.Count))
+		table = append(table, row)
+	}
+	return alignTable(table)
+}
+
+// parseWindow 解析时间范围参数，返回 [from, to)：
+// 空为最近 7 天；"7d" / "24h" / "2w" 为相对现在；一个日期为当天；两个日期为闭区间
+func parseWindow(args []string, now time.Time) (time.Time, time.Time, error) {
+	now = now.In(bank.Shanghai)
+	switch len(args) {
+	case 0:
+		return now.AddDate(0, 0, -7), now, nil
+	case 1:
+		if d, err := parseDay(args[0]); err == nil {
+			return d, d.AddDate(0, 0, 1), nil
+		}
+		dur, err := parseSpan(args[0])
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		return now.Add(-dur), now, nil
+	default:
+		from, err1 := parseDay(args[0])
+		to, err2 := parseDay(args[1])
+		if err1 != nil || err2 != nil {
+			return time.Time{}, time.Time{}, errors.New("日期格式不正确，请使用 2026-01-02 这样的格式。")
+		}
+		if to.Before(from) {
+			from, to = to, from
+		}
+		if to.Sub(from) > historyMaxDays*24*time.Hour {
+			return time.Time{}, time.Time{}, fmt.Errorf("时间范围最多 %d 天。", historyMaxDays)
+		}
+		return from, to.AddDate(0, 0, 1), nil
+	}
+}
+
+// parseSpan 解析 "7d" / "24h" / "2w"
+func parseSpan(s string) (time.Duration, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if len(s) < 2 {
+		return 0, errors.New("时间范围格式不正确。")
+	}
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n <= 0 {
+		return 0, errors.New("时间范围格式不正确。")
+	}
+	var d time.Duration
+	switch s[len(s)-1] {
+	case 'h':
+		d = time.Duration(n) * time.Hour
+	case 'd':
+		d = time.Duration(n) * 24 * time.Hour
+	case 'w':
+		d = time.Duration(n) * 7 * 24 * time.Hour
+	default:
+		return 0, errors.New("时间范围格式不正确。")
+	}
+	if d > historyMaxDays*24*time.Hour {
+		return 0, fmt.Errorf("时间范围最多 %d 天。", historyMaxDays)
+	}
+	return d, nil
+}
+
+// parseDay 解析 Asia/Shanghai 的日期
+func parseDay(s string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", "2006/01/02", "20060102"} {
+		if t, err := time.ParseInLocation(layout, s, bank.Shanghai); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("日期格式不正确")
+}
+
+func formatDay(t time.Time) string {
+	return t.In(bank.Shanghai).Format("2006-01-02")
+}
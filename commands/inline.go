@@ -0,0 +1,154 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/shopspring/decimal"
+
+	"aki.telegram.bot.fxrate/bank"
+	"aki.telegram.bot.fxrate/currency"
+	"aki.telegram.bot.fxrate/money"
+	"aki.telegram.bot.fxrate/tools"
+)
+
+// inlineTimeout 内联查询须在几秒内应答，取不到的来源直接略过
+const inlineTimeout = 8 * time.Second
+
+// inlineCacheTime Telegram 端缓存内联结果的秒数，与来源缓存时长相当
+const inlineCacheTime = 60
+
+// inlineDefaultAmount 未给金额时按 100 换算
+var inlineDefaultAmount = decimal.NewFromInt(100)
+
+const inlineUsage = "用法: @机器人 [币种] [金额] [目标币种]\n" +
+	"示例: usd 100、100usd、cny 1000 jpy、hkd 500 cash"
+
+// inlineAmountCcy "100usd"、"100美元" 这类金额与币种连写
+var inlineAmountCcy = regexp.MustCompile(`^([0-9][0-9.,]*)(\D+)$`)
+
+// HandleInlineQuery 内联模式：在任意会话输入 "@bot usd 100"，返回最优、各银行与银联的换算结果
+func HandleInlineQuery(ctx context.Context, b *bot.Bot, iq *models.InlineQuery) {
+	if iq == nil {
+		return
+	}
+	if strings.TrimSpace(iq.Query) == "" {
+		tools.AnswerInlineQuery(ctx, b, iq.ID, []models.InlineQueryResult{inlineArticle("usage", "输入币种与金额，例如 usd 100", inlineUsage, inlineUsage)}, inlineCacheTime)
+		return
+	}
+	from, to, amount, opts, err := parseInlineQuery(iq.Query)
+	if err != nil {
+		tools.AnswerInlineQuery(ctx, b, iq.ID, []models.InlineQueryResult{inlineArticle("usage", err.Error(), inlineUsage, inlineUsage)}, 0)
+		return
+	}
+
+	routes, _ := collectBestRoutes(ctx, from, to, amount, opts, inlineTimeout)
+	var results []models.InlineQueryResult
+	if len(routes) > 0 {
+		r := routes[0]
+		results = append(results, inlineArticle("best",
+			fmt.Sprintf("最优 %s: %s %s ≈ %s %s", r.BankNameCN, money.Format(amount, from), from, money.Format(r.Result, to), to),
+			fmt.Sprintf("%s，共比较 %d 家", r.Detail, len(routes)),
+			renderBest(from, to, amount, routes)))
+	}
+	for _, r := range routes {
+		title := fmt.Sprintf("%s: %s %s ≈ %s %s", r.BankNameCN, money.Format(amount, from), from, money.Format(r.Result, to), to)
+		results = append(results, inlineArticle(r.BankKey, title,
+			fmt.Sprintf("%s，发布时间: %s", r.Detail, r.ReleaseTime),
+			fmt.Sprintf("%s\n%s\n发布时间: %s", title, r.Detail, r.ReleaseTime)))
+	}
+	if a := unionPayArticle(ctx, from, to, amount); a != nil {
+		results = append(results, a)
+	}
+	if len(results) == 0 {
+		msg := fmt.Sprintf("没有来源能提供 %s -> %s 的有效牌价", from, to)
+		results = append(results, inlineArticle("none", msg, inlineUsage, msg))
+	}
+	tools.AnswerInlineQuery(ctx, b, iq.ID, results, inlineCacheTime)
+}
+
+// parseInlineQuery 解析 "usd 100"、"100usd"、"cny 1000 jpy"，第一个币种为源币种，第二个为目标（默认 CNY）
+func parseInlineQuery(q string) (from, to string, amount decimal.Decimal, opts rateOpts, err error) {
+	var tokens []string
+	for _, t := range strings.Fields(q) {
+		if m := inlineAmountCcy.FindStringSubmatch(t); m != nil {
+			tokens = append(tokens, m[1], m[2])
+			continue
+		}
+		tokens = append(tokens, t)
+	}
+	// parseRateFlags 跳过下标 0（命令本身），补一个占位
+	fields, opts := parseRateFlags(append([]string{""}, tokens...))
+
+	var codes []string
+	hasAmount := false
+	for _, t := range fields[1:] {
+		if v, ok := ParseAmount(t); ok && !hasAmount {
+			amount, hasAmount = v, true
+			continue
+		}
+		c, rerr := currency.Resolve(t)
+		if rerr != nil {
+			var amb *currency.AmbiguousError
+			if errors.As(rerr, &amb) {
+				return "", "", amount, opts, fmt.Errorf("「%s」可能指多个币种，请改用币种代码", t)
+			}
+			return "", "", amount, opts, fmt.Errorf("无法识别「%s」", t)
+		}
+		codes = append(codes, c.Code)
+	}
+	switch {
+	case len(codes) == 0:
+		return "", "", amount, opts, errors.New("请输入币种，例如 usd 100")
+	case len(codes) > 2:
+		return "", "", amount, opts, errors.New("最多两个币种：源币种与目标币种")
+	}
+	from, to = codes[0], "CNY"
+	if len(codes) == 2 {
+		to = codes[1]
+	}
+	if strings.EqualFold(from, to) {
+		return "", "", amount, opts, errors.New("源币种与目标币种相同，请再输入一个外币")
+	}
+	if !hasAmount {
+		amount = inlineDefaultAmount
+	}
+	if !amount.IsPositive() {
+		return "", "", amount, opts, errors.New("金额须为正数")
+	}
+	return from, to, amount, opts, nil
+}
+
+// unionPayArticle 银联只有参考汇率，单独按其直接汇率换算；没有该币种对时返回 nil
+func unionPayArticle(ctx context.Context, from, to string, amount decimal.Decimal) models.InlineQueryResult {
+	ctx, cancel := context.WithTimeout(ctx, inlineTimeout)
+	defer cancel()
+	rate, found, err := bank.GetUnionPayRate(ctx, from, to)
+	if err != nil || !found {
+		if err != nil && !errorsIsRateNotFound(err) {
+			tools.LogError("inline: 银联获取失败: %v", err)
+		}
+		return nil
+	}
+	out := money.Round(amount.Mul(mustParseRate(rate.Rate)), to, money.HalfUp)
+	title := fmt.Sprintf("银联: %s %s ≈ %s %s", money.Format(amount, from), from, money.Format(out, to), to)
+	detail := fmt.Sprintf("汇率 1 %s = %s %s", from, rate.Rate, to)
+	return inlineArticle("unionpay-direct", title,
+		fmt.Sprintf("%s，发布时间: %s", detail, rate.ReleaseTime),
+		fmt.Sprintf("%s\n%s\n发布时间: %s", title, detail, rate.ReleaseTime))
+}
+
+func inlineArticle(id, title, description, text string) *models.InlineQueryResultArticle {
+	return &models.InlineQueryResultArticle{
+		ID:                  id,
+		Title:               title,
+		Description:         description,
+		InputMessageContent: &models.InputTextMessageContent{MessageText: text},
+	}
+}
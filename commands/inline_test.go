@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"aki.telegram.bot.fxrate/bank"
+)
+
+func TestParseInlineQuery(t *testing.T) {
+	tests := []struct {
+		q        string
+		from, to string
+		amount   string
+		opts     rateOpts
+	}{
+		{"usd 100", "USD", "CNY", "100", rateOpts{}},
+		{"100usd", "USD", "CNY", "100", rateOpts{}},
+		{"100美元", "USD", "CNY", "100", rateOpts{}},
+		{"usd", "USD", "CNY", "100", rateOpts{}}, // 未给金额按 100
+		{"cny 1000 jpy", "CNY", "JPY", "1000", rateOpts{}},
+		{"1000 cny usd", "CNY", "USD", "1000", rateOpts{}},
+		{"100 美元 日元", "USD", "JPY", "100", rateOpts{}},
+		{"hkd 2,500 cash", "HKD", "CNY", "2500", rateOpts{Method: bank.Cash}},
+		{"usd 100 mid", "USD", "CNY", "100", rateOpts{Side: bank.MidSide}},
+		{"usd 1.5 sell", "USD", "CNY", "1.5", rateOpts{Side: bank.SellSide}},
+		{"100USD jpy buy 现钞", "USD", "JPY", "100", rateOpts{Side: bank.BuySide, Method: bank.Cash}},
+	}
+	for _, tt := range tests {
+		from, to, amount, opts, err := parseInlineQuery(tt.q)
+		if err != nil {
+			t.Errorf("parseInlineQuery(%q) error: %v", tt.q, err)
+			continue
+		}
+		if from != tt.from || to != tt.to || !amount.Equal(decimal.RequireFromString(tt.amount)) || opts != tt.opts {
+			t.Errorf("parseInlineQuery(%q) = %s %s %s %+v, want %s %s %s %+v",
+				tt.q, from, to, amount, opts, tt.from, tt.to, tt.amount, tt.opts)
+		}
+	}
+}
+
+func TestParseInlineQueryErrors(t *testing.T) {
+	tests := []struct {
+		q    string
+		want string
+	}{
+		{"100", "请输入币种，例如 usd 100"},
+		{"foo 1", "无法识别「foo」"},
+		{"dollar 100", "「dollar」可能指多个币种，请改用币种代码"},
+		{"usd hkd eur", "最多两个币种：源币种与目标币种"},
+		{"cny 100", "源币种与目标币种相同，请再输入一个外币"},
+		{"usd 0", "金额须为正数"},
+		{"usd -5", "金额须为正数"},
+	}
+	for _, tt := range tests {
+		_, _, _, _, err := parseInlineQuery(tt.q)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("parseInlineQuery(%q) error = %v, want %q", tt.q, err, tt.want)
+		}
+	}
+}
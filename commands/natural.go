@@ -0,0 +1,342 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/shopspring/decimal"
+
+	"aki.telegram.bot.fxrate/bank"
+	"aki.telegram.bot.fxrate/currency"
+	"aki.telegram.bot.fxrate/settings"
+	"aki.telegram.bot.fxrate/tools"
+)
+
+// naturalMaxLen 超过这个长度的消息多半是聊天，不做识别
+const naturalMaxLen = 60
+
+// naturalWordLen 中文连写时单个词最长的字数（如“寰宇人生”“可以换成”）
+const naturalWordLen = 6
+
+// naturalFillers 可以忽略的连接词与语气词
+var naturalFillers = map[string]bool{
+	"to": true, "in": true, "into": true, "is": true, "how": true, "much": true, "what": true, "whats": true, "convert": true,
+	"换": true, "兑": true, "换成": true, "兑成": true, "兑换": true, "换算": true, "换多少": true, "可以换": true, "能换": true,
+	"等于": true, "是": true, "多少": true, "合": true, "折合": true, "约": true, "大概": true, "在": true, "用": true,
+	"的": true, "呢": true, "吗": true, "啊": true, "了": true,
+}
+
+// naturalPunct 分隔用的标点
+const naturalPunct = "?？,，。.!！:：~～=→>-"
+
+// naturalSymbols 货币符号与口语单位，¥ 按人民币处理
+var naturalSymbols = map[string]string{
+	"¥": "CNY", "￥": "CNY", "元": "CNY", "块": "CNY", "块钱": "CNY",
+	"$": "USD", "us$": "USD", "hk$": "HKD", "s$": "SGD", "a$": "AUD", "c$": "CAD", "nt$": "TWD",
+	"€": "EUR", "£": "GBP", "₩": "KRW",
+}
+
+// naturalBanks 银行简称；完整中文名与命令 key 直接查注册表
+var naturalBanks = map[string]string{
+	"中行": "boc", "招行": "cmb", "兴业": "cib", "中信": "citic", "广发": "cgb", "银联": "unionpay",
+}
+
+// naturalMultipliers 紧跟数字的数量单位
+var naturalMultipliers = map[rune]int64{'万': 10000, 'w': 10000, 'W': 10000, '千': 1000, 'k': 1000, 'K': 1000}
+
+// naturalQuery 从一句话里识别出的换算
+type naturalQuery struct {
+	From, To string
+	Amount   decimal.Decimal
+	Bank     string // 指定银行时只按该行换算，否则比对各行
+	Opts     rateOpts
+}
+
+// naturalItem 识别出的片段：金额、币种或其它（连接词、银行、选项）
+type naturalItem struct {
+	amount bool
+	code   string
+}
+
+// HandleNaturalText 开启了自然语言换算的会话里，识别 "100usd"、"1万日元多少人民币" 这类消息并回复换算结果；
+// 识别不了的消息一律不回应，免得打扰正常聊天
+func HandleNaturalText(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update == nil || update.Message == nil || !naturalEnabled(update.Message.Chat) {
+		return
+	}
+	q, ok := parseNatural(update.Message.Text)
+	if !ok {
+		return
+	}
+	switch q.Bank {
+	case "":
+		sendBest(ctx, b, update, q.From, q.To, q.Amount, q.Opts)
+	case "unionpay":
+		// 银联只有参考汇率，与 /uniopay 一样按币种对直接换算
+		handleUnionPayConvert(ctx, b, update, q.From, q.To, q.Amount)
+	default:
+		handleConvert(ctx, b, update, q.Bank, q.From, q.To, q.Amount, q.Opts)
+	}
+}
+
+// naturalEnabled 会话是否开启自然语言换算；没有设置过时私聊默认开启、群组默认关闭
+func naturalEnabled(chat models.Chat) bool {
+	if s := settings.Default(); s != nil {
+		c, err := s.Get(chat.ID)
+		if err == nil {
+			return c.Natural
+		}
+		if !errors.Is(err, settings.ErrNotFound) {
+			tools.LogError("settings: 读取会话 %d 失败: %v", chat.ID, err)
+			return false
+		}
+	}
+	return chat.Type == models.ChatTypePrivate
+}
+
+// parseNatural 识别一句话里的金额、源币种、目标币种（默认 CNY）、银行与牌价选项；
+// 出现无法识别的词时整句放弃
+func parseNatural(text string) (q naturalQuery, ok bool) {
+	text = strings.TrimSpace(text)
+	if text == "" || utf8.RuneCountInString(text) > naturalMaxLen {
+		return q, false
+	}
+	rs := []rune(text)
+	var items []naturalItem
+	hasAmount := false
+
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r) || strings.ContainsRune(naturalPunct, r):
+			i++
+
+		case r >= '0' && r <= '9':
+			j := i
+			for j < len(rs) && (rs[j] >= '0' && rs[j] <= '9' || rs[j] == '.' || rs[j] == ',') {
+				j++
+			}
+			amount, valid := ParseAmount(strings.TrimRight(string(rs[i:j]), ".,"))
+			if !valid || hasAmount {
+				return q, false
+			}
+			// k/w 后面紧跟字母时是币种代码（如 100krw），不是单位
+			if j < len(rs) {
+				if m, isMul := naturalMultipliers[rs[j]]; isMul && (rs[j] > unicode.MaxASCII || j+1 == len(rs) || !isASCIILetter(rs[j+1])) {
+					amount = amount.Mul(decimal.NewFromInt(m))
+					j++
+				}
+			}
+			q.Amount, hasAmount = amount, true
+			items = append(items, naturalItem{amount: true})
+			i = j
+
+		case isASCIILetter(r) || r == '$':
+			j := i
+			for j < len(rs) && isASCIILetter(rs[j]) {
+				j++
+			}
+			if j < len(rs) && rs[j] == '$' {
+				j++
+			}
+			item, known := naturalWord(string(rs[i:j]), &q)
+			if !known {
+				return q, false
+			}
+			items = append(items, item)
+			i = j
+
+		default:
+			// 中文连写：从当前位置起取能识别的最长词
+			j := i
+			for j < len(rs) && rs[j] > unicode.MaxASCII && !unicode.IsSpace(rs[j]) && !strings.ContainsRune(naturalPunct, rs[j]) {
+				j++
+			}
+			n := 0
+			var item naturalItem
+			for l := min(j-i, naturalWordLen); l > 0; l-- {
+				if it, known := naturalWord(string(rs[i:i+l]), &q); known {
+					n, item = l, it
+					break
+				}
+			}
+			if n == 0 {
+				return q, false
+			}
+			items = append(items, item)
+			i += n
+		}
+	}
+	if !hasAmount || !q.Amount.IsPositive() {
+		return q, false
+	}
+
+	// 紧挨金额的币种是源币种：前面的优先（"usd 100 jpy"、"$100"），其次后面的（"100usd"）
+	var codes []string
+	src := ""
+	for _, it := range items {
+		if it.code == "" {
+			continue
+		}
+		if !containsFold(codes, it.code) {
+			codes = append(codes, it.code)
+		}
+	}
+	for i, it := range items {
+		if !it.amount {
+			continue
+		}
+		if i > 0 && items[i-1].code != "" {
+			src = items[i-1].code
+		} else if i+1 < len(items) && items[i+1].code != "" {
+			src = items[i+1].code
+		}
+	}
+	if len(codes) == 0 || len(codes) > 2 {
+		return q, false
+	}
+	if src == "" {
+		src = codes[0]
+	}
+	q.From, q.To = src, "CNY"
+	for _, c := range codes {
+		if c != src {
+			q.To = c
+		}
+	}
+	if q.From == q.To {
+		// 只提到人民币（如 "100元"）时不知道要换成什么
+		return q, false
+	}
+	return q, true
+}
+
+// naturalWord 识别单个词：连接词、牌价选项、银行、货币符号或币种
+func naturalWord(w string, q *naturalQuery) (naturalItem, bool) {
+	lw := strings.ToLower(w)
+	if naturalFillers[lw] {
+		return naturalItem{}, true
+	}
+	if set, ok := rateFlags[lw]; ok {
+		set(&q.Opts)
+		return naturalItem{}, true
+	}
+	if key, ok := naturalBank(lw); ok {
+		if q.Bank != "" && q.Bank != key {
+			return naturalItem{}, false
+		}
+		q.Bank = key
+		return naturalItem{}, true
+	}
+	if code, ok := naturalSymbols[lw]; ok {
+		return naturalItem{code: code}, true
+	}
+	if c, ok := currency.Lookup(lw); ok {
+		return naturalItem{code: c.Code}, true
+	}
+	return naturalItem{}, false
+}
+
+// naturalBank 按命令 key、中文名或简称识别银行
+func naturalBank(w string) (string, bool) {
+	if key, ok := naturalBanks[w]; ok {
+		return key, true
+	}
+	if p, ok := bank.Lookup(w); ok {
+		return p.Key(), true
+	}
+	for _, p := range bank.Providers() {
+		if p.Name() == w {
+			return p.Key(), true
+		}
+	}
+	return "", false
+}
+
+func isASCIILetter(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+}
+
+func containsFold(xs []string, s string) bool {
+	for _, x := range xs {
+		if strings.EqualFold(x, s) {
+			return true
+		}
+	}
+	return false
+}
+
+const autoUsage = "用法: /auto on|off\n" +
+	"开启后直接发送 \"100usd\"、\"1万日元多少人民币\"、\"hkd 2,500 to cny\"、\"¥500 in euro\"、\"招行 1k 美元\" 即可换算；\n" +
+	"未指定银行时比对各行给出最优结果。私聊默认开启，群组默认关闭，群组内仅管理员可以修改。"
+
+// HandleAutoCommand /auto on|off：开关本会话的自然语言换算
+func HandleAutoCommand(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update == nil || update.Message == nil {
+		return
+	}
+	chat, threadID := update.Message.Chat, update.Message.MessageThreadID
+	fields := strings.Fields(update.Message.Text)
+	if len(fields) < 2 {
+		state := "关闭"
+		if naturalEnabled(chat) {
+			state = "开启"
+		}
+		tools.SendMessage(ctx, b, chat.ID, fmt.Sprintf("本会话自然语言换算: %s\n\n%s", state, autoUsage), threadID, "")
+		return
+	}
+
+	var on bool
+	switch strings.ToLower(fields[1]) {
+	case "on", "开", "开启":
+		on = true
+	case "off", "关", "关闭":
+		on = false
+	default:
+		tools.SendMessage(ctx, b, chat.ID, autoUsage, threadID, "")
+		return
+	}
+
+	s := settings.Default()
+	if s == nil {
+		tools.SendMessage(ctx, b, chat.ID, "未启用数据存储，无法保存会话设置。", threadID, "")
+		return
+	}
+	var userID int64
+	if update.Message.From != nil {
+		userID = update.Message.From.ID
+	}
+	if chat.Type != models.ChatTypePrivate && !isChatAdmin(ctx, b, chat.ID, userID) {
+		tools.SendMessage(ctx, b, chat.ID, "只有群管理员可以修改这项设置。", threadID, "")
+		return
+	}
+	if err := s.Put(&settings.Chat{ChatID: chat.ID, Natural: on, UpdatedBy: userID}); err != nil {
+		tools.LogError("settings: 保存会话 %d 失败: %v", chat.ID, err)
+		tools.SendMessage(ctx, b, chat.ID, "保存失败，请稍后再试。", threadID, "")
+		return
+	}
+	if on {
+		tools.SendMessage(ctx, b, chat.ID, "已开启自然语言换算，直接发送 \"100usd\" 试试。", threadID, "")
+		return
+	}
+	tools.SendMessage(ctx, b, chat.ID, "已关闭自然语言换算。", threadID, "")
+}
+
+// isChatAdmin userID 是否为群主或管理员；查询失败时按否处理
+func isChatAdmin(ctx context.Context, b *bot.Bot, chatID, userID int64) bool {
+	if userID == 0 {
+		return false
+	}
+	m, err := b.GetChatMember(ctx, &bot.GetChatMemberParams{ChatID: chatID, UserID: userID})
+	if err != nil {
+		tools.LogError("auto: 查询群成员失败: %v", err)
+		return false
+	}
+	return m.Type == models.ChatMemberTypeOwner || m.Type == models.ChatMemberTypeAdministrator
+}
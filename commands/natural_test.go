@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"aki.telegram.bot.fxrate/bank"
+)
+
+func TestParseNatural(t *testing.T) {
+	tests := []struct {
+		text     string
+		from, to string
+		amount   string
+		bank     string
+		opts     rateOpts
+	}{
+		{"100usd", "USD", "CNY", "100", "", rateOpts{}},
+		{"$100", "USD", "CNY", "100", "", rateOpts{}},
+		{"us$20", "USD", "CNY", "20", "", rateOpts{}},
+		{"100krw", "KRW", "CNY", "100", "", rateOpts{}}, // k 后跟字母是币种代码
+		{"5w jpy", "JPY", "CNY", "50000", "", rateOpts{}},
+		{"1万日元多少人民币", "JPY", "CNY", "10000", "", rateOpts{}},
+		{"3千港币", "HKD", "CNY", "3000", "", rateOpts{}},
+		{"hkd 2,500 to cny", "HKD", "CNY", "2500", "", rateOpts{}},
+		{"¥500 in euro", "CNY", "EUR", "500", "", rateOpts{}},
+		{"usd 100 jpy", "USD", "JPY", "100", "", rateOpts{}}, // 金额前的币种优先
+		{"招行 1k 美元", "USD", "CNY", "1000", "cmb", rateOpts{}},
+		{"1.5k 银联 eur", "EUR", "CNY", "1500", "unionpay", rateOpts{}},
+		{"100 usd cash 中行", "USD", "CNY", "100", "boc", rateOpts{Method: bank.Cash}},
+	}
+	for _, tt := range tests {
+		q, ok := parseNatural(tt.text)
+		if !ok {
+			t.Errorf("parseNatural(%q) not recognised", tt.text)
+			continue
+		}
+		if q.From != tt.from || q.To != tt.to || !q.Amount.Equal(decimal.RequireFromString(tt.amount)) || q.Bank != tt.bank || q.Opts != tt.opts {
+			t.Errorf("parseNatural(%q) = %s %s %s %q %+v, want %s %s %s %q %+v",
+				tt.text, q.From, q.To, q.Amount, q.Bank, q.Opts, tt.from, tt.to, tt.amount, tt.bank, tt.opts)
+		}
+	}
+}
+
+func TestParseNaturalIgnored(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"hello",
+		"今天天气不错",
+		"100",             // 没有币种
+		"100元",            // 只有人民币
+		"usd",             // 没有金额
+		"2 usd 3",         // 两个金额
+		"100 usd eur jpy", // 三个币种
+		"招行 中行 100 usd",   // 两家银行
+		"0 usd",
+		"100 usd 这个价格可以接受吗我觉得还是有点贵了，要不要再等等看明天会不会降一点再换比较合适呢",
+	} {
+		if q, ok := parseNatural(text); ok {
+			t.Errorf("parseNatural(%q) = %+v, want ignored", text, q)
+		}
+	}
+}
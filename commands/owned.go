@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"aki.telegram.bot.fxrate/bucket"
+	"aki.telegram.bot.fxrate/tools"
+)
+
+// ownedStore 按会话存放、记录添加人的数据（提醒、订阅）
+type ownedStore[T any] interface {
+	ByChat(chatID int64) ([]T, error)
+	Get(id uint64) (*T, error)
+	Delete(id uint64) error
+}
+
+// ownedKind 删除时回复里用到的名称
+type ownedKind struct {
+	Tag     string // 日志前缀，如 "alert"
+	Noun    string // 如 "提醒"
+	Command string // 如 "/alert"
+}
+
+// deleteOwned 处理 "<命令> del [编号...|all]"：只能删除本会话里自己添加的，私聊不限；
+// owner 取出记录的 ID、所属会话与添加人
+func deleteOwned[T any](ctx context.Context, b *bot.Bot, update *models.Update, store ownedStore[T], kind ownedKind,
+	owner func(*T) (id uint64, chatID, userID int64), args []string) {
+	chatID, threadID := update.Message.Chat.ID, update.Message.MessageThreadID
+	if len(args) == 0 {
+		tools.SendMessage(ctx, b, chatID, fmt.Sprintf("用法: %s del [编号|all]", kind.Command), threadID, "")
+		return
+	}
+	var userID int64
+	if update.Message.From != nil {
+		userID = update.Message.From.ID
+	}
+	owned := func(v *T) bool {
+		_, c, u := owner(v)
+		return c == chatID && (u == userID || update.Message.Chat.Type == models.ChatTypePrivate)
+	}
+
+	if strings.EqualFold(args[0], "all") {
+		items, err := store.ByChat(chatID)
+		if err != nil {
+			tools.LogError("%s: 读取失败: %v", kind.Tag, err)
+			tools.SendMessage(ctx, b, chatID, fmt.Sprintf("读取%s失败，请稍后再试。", kind.Noun), threadID, "")
+			return
+		}
+		n := 0
+		for i := range items {
+			id, _, _ := owner(&items[i])
+			if owned(&items[i]) && store.Delete(id) == nil {
+				n++
+			}
+		}
+		tools.SendMessage(ctx, b, chatID, fmt.Sprintf("已删除 %d 条%s。", n, kind.Noun), threadID, "")
+		return
+	}
+
+	var deleted []string
+	for _, a := range args {
+		id, err := strconv.ParseUint(strings.TrimPrefix(a, "#"), 10, 64)
+		if err != nil {
+			tools.SendMessage(ctx, b, chatID, fmt.Sprintf("编号「%s」不正确。", html.EscapeString(a)), threadID, "")
+			return
+		}
+		v, err := store.Get(id)
+		if err == nil {
+			if _, c, _ := owner(v); c != chatID {
+				err = bucket.ErrNotFound
+			}
+		}
+		if err != nil {
+			tools.SendMessage(ctx, b, chatID, fmt.Sprintf("本会话没有%s #%d。", kind.Noun, id), threadID, "")
+			return
+		}
+		if !owned(v) {
+			tools.SendMessage(ctx, b, chatID, fmt.Sprintf("%s #%d 不是你添加的，不能删除。", kind.Noun, id), threadID, "")
+			return
+		}
+		if err := store.Delete(id); err != nil && !errors.Is(err, bucket.ErrNotFound) {
+			tools.LogError("%s: 删除 #%d 失败: %v", kind.Tag, id, err)
+			tools.SendMessage(ctx, b, chatID, "删除失败，请稍后再试。", threadID, "")
+			return
+		}
+		deleted = append(deleted, fmt.Sprintf("#%d", id))
+	}
+	tools.SendMessage(ctx, b, chatID, fmt.Sprintf("已删除%s %s。", kind.Noun, strings.Join(deleted, " ")), threadID, "")
+}
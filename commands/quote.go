@@ -0,0 +1,258 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"aki.telegram.bot.fxrate/bank"
+	"aki.telegram.bot.fxrate/currency"
+	"aki.telegram.bot.fxrate/tools"
+)
+
+// QuotePrefix 单币种牌价按钮的回调前缀，格式 "q:<视图>:<银行>:<币种>:<s|c>"，
+// 视图 l 为单家牌价、c 为各银行对比，大写（L/C）表示刷新；s/c 为现汇/现钞
+const QuotePrefix = "q:"
+
+const (
+	quoteLookup  = "l"
+	quoteCompare = "c"
+)
+
+// quoteRefreshMin 刷新时缓存不足这个时长就直接沿用，免得连点刷新反复请求银行
+const quoteRefreshMin = 15 * time.Second
+
+// quoteCurrencies 切换币种时给出的常用币种
+var quoteCurrencies = []string{"USD", "EUR", "HKD", "JPY", "GBP", "AUD"}
+
+// quoteBanksPerRow 切换银行按钮每行个数
+const quoteBanksPerRow = 4
+
+// errQuoteNotFound 该来源没有这个币种
+var errQuoteNotFound = errors.New("quote not found")
+
+const staleButton = "按钮已失效，请重新发送命令"
+
+// handleQuote /<bank> <币种>：发送单家牌价，并附上切换银行、币种、现汇/现钞、刷新与对比按钮
+func handleQuote(ctx context.Context, b *bot.Bot, update *models.Update, key, q string) {
+	chatID, threadID := update.Message.Chat.ID, update.Message.MessageThreadID
+	c, err := currency.Resolve(q)
+	if err != nil {
+		tools.SendMessage(ctx, b, chatID, "未找到该币种，请尝试币种代码（如: USD/HKD）或中文名。", threadID, "")
+		return
+	}
+	text, markup, err := renderQuote(ctx, key, c.Code, false)
+	switch {
+	case errors.Is(err, errQuoteNotFound):
+		tools.SendMessage(ctx, b, chatID, "未找到该币种，请尝试币种代码（如: USD/HKD）或中文名。", threadID, "")
+	case err != nil:
+		tools.LogError("%s fetch error: %v", key, err)
+		tools.SendMessage(ctx, b, chatID, "查询失败，请稍后再试。", threadID, "")
+	default:
+		tools.SendMessageWithMarkup(ctx, b, chatID, text, threadID, "HTML", markup)
+	}
+}
+
+// HandleQuoteCallback 按钮回调：重新渲染对应视图并原地修改消息
+func HandleQuoteCallback(ctx context.Context, b *bot.Bot, cq *models.CallbackQuery) {
+	view, key, code, cash, refresh, ok := parseQuoteData(cq.Data)
+	msg := cq.Message.Message
+	if !ok || msg == nil {
+		tools.AnswerCallbackQuery(ctx, b, cq.ID, staleButton)
+		return
+	}
+	if _, known := bank.Lookup(key); !known {
+		tools.AnswerCallbackQuery(ctx, b, cq.ID, staleButton)
+		return
+	}
+	if refresh {
+		if view == quoteCompare {
+			for _, p := range bank.Providers() {
+				bank.Refresh(p.Key(), quoteRefreshMin)
+			}
+		} else {
+			bank.Refresh(key, quoteRefreshMin)
+		}
+	}
+
+	if view == quoteCompare {
+		// 对比要逐家抓取，先应答免得按钮一直转圈
+		tools.AnswerCallbackQuery(ctx, b, cq.ID, "正在对比各银行…")
+		text, markup, err := renderQuoteCompare(ctx, key, code, cash)
+		if err != nil {
+			tools.LogError("quote compare %s: %v", code, err)
+			return
+		}
+		editQuote(ctx, b, msg, text, markup)
+		return
+	}
+
+	text, markup, err := renderQuote(ctx, key, code, cash)
+	switch {
+	case errors.Is(err, errQuoteNotFound):
+		p, _ := bank.Lookup(key)
+		tools.AnswerCallbackQuery(ctx, b, cq.ID, fmt.Sprintf("%s没有 %s 的牌价", p.Name(), code))
+		return
+	case err != nil:
+		tools.LogError("quote %s %s: %v", key, code, err)
+		tools.AnswerCallbackQuery(ctx, b, cq.ID, "查询失败，请稍后再试")
+		return
+	}
+	tools.AnswerCallbackQuery(ctx, b, cq.ID, "")
+	editQuote(ctx, b, msg, text, markup)
+}
+
+// editQuote 原地修改；内容未变时 Telegram 会报错，忽略即可
+func editQuote(ctx context.Context, b *bot.Bot, msg *models.Message, text string, markup models.ReplyMarkup) {
+	_ = tools.EditMessageText(ctx, b, msg.Chat.ID, msg.ID, text, "HTML", markup)
+}
+
+// quoteData 编码回调数据
+func quoteData(view, key, code string, cash bool) string {
+	mode := "s"
+	if cash {
+		mode = "c"
+	}
+	return QuotePrefix + strings.Join([]string{view, key, code, mode}, ":")
+}
+
+// quoteRefreshData 刷新按钮的回调数据：视图大写
+func quoteRefreshData(view, key, code string, cash bool) string {
+	return quoteData(strings.ToUpper(view), key, code, cash)
+}
+
+// parseQuoteData 解码回调数据，格式不对（如旧版本留下的按钮）时 ok 为 false
+func parseQuoteData(data string) (view, key, code string, cash, refresh, ok bool) {
+	parts := strings.Split(strings.TrimPrefix(data, QuotePrefix), ":")
+	if len(parts) != 4 || parts[1] == "" || parts[2] == "" {
+		return "", "", "", false, false, false
+	}
+	view = strings.ToLower(parts[0])
+	if view != quoteLookup && view != quoteCompare {
+		return "", "", "", false, false, false
+	}
+	if parts[3] != "s" && parts[3] != "c" {
+		return "", "", "", false, false, false
+	}
+	return view, parts[1], parts[2], parts[3] == "c", parts[0] != view, true
+}
+
+// quoteFields 现汇或现钞的买入、卖出价
+func quoteFields(cash bool) (buy, sell bank.Field) {
+	if cash {
+		return bank.BuyCash, bank.SellCash
+	}
+	return bank.BuySpot, bank.SellSpot
+}
+
+func quoteKind(cash bool) string {
+	if cash {
+		return "现钞"
+	}
+	return "现汇"
+}
+
+// renderQuote 渲染单家某币种的全部牌价（每100外币），当前选中的现汇或现钞加粗
+func renderQuote(ctx context.Context, key, code string, cash bool) (string, models.ReplyMarkup, error) {
+	p, ok := bank.Lookup(key)
+	if !ok {
+		return "", nil, fmt.Errorf("unknown provider %q", key)
+	}
+	snap, err := p.Snapshot(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	q, found := snap.Find(code)
+	if !found {
+		return "", nil, errQuoteNotFound
+	}
+
+	buy, sell := quoteFields(cash)
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("<b>%s外汇牌价 — %s (%s)</b>（每100外币）\n\n",
+		html.EscapeString(p.Name()), html.EscapeString(q.Name), html.EscapeString(q.Code)))
+	// 只列出有报价的项（如中信没有现钞、银联只有参考汇率）
+	listed := 0
+	for _, f := range bank.Fields {
+		v := q.Per(f, 100)
+		if !v.Valid {
+			continue
+		}
+		line := fmt.Sprintf("%s: %s", f.Label(), bank.FormatPrice(v))
+		if f == buy || f == sell {
+			line = "<b>" + line + "</b>"
+		}
+		sb.WriteString(line + "\n")
+		listed++
+	}
+	if listed == 0 {
+		sb.WriteString("暂无报价\n")
+	}
+	if prog, ok := bank.LookupProgramme(key); ok {
+		sb.WriteString(fmt.Sprintf("\n优惠规则: %s\n", html.EscapeString(prog.Describe())))
+	}
+	sb.WriteString(fmt.Sprintf("\n发布时间: %s\n", bank.FormatTime(q.ReleaseTime)))
+	sb.WriteString(fmt.Sprintf("数据获取于: %s", snap.FetchedAt.In(bank.Shanghai).Format(time.TimeOnly)))
+	return sb.String(), quoteKeyboard(key, q.Code, cash), nil
+}
+
+// renderQuoteCompare 各银行对比，附返回与刷新按钮
+func renderQuoteCompare(ctx context.Context, key, code string, cash bool) (string, models.ReplyMarkup, error) {
+	rows, timeoutKeys := fetchAllQuotes(ctx, code, "quote")
+	if len(rows) == 0 {
+		return "", nil, fmt.Errorf("no provider has %s", code)
+	}
+	buy, sell := quoteFields(cash)
+	text := renderCompare(code, rows, buy, sell, bank.Middle)
+	if len(timeoutKeys) > 0 {
+		text += fmt.Sprintf("\n提醒：以下银行查询超时（>20s）：%s", html.EscapeString(strings.Join(mapBankNames(timeoutKeys), ", ")))
+	}
+	p, _ := bank.Lookup(key)
+	markup := &models.InlineKeyboardMarkup{InlineKeyboard: [][]models.InlineKeyboardButton{{
+		{Text: "◀ 返回" + p.Name(), CallbackData: quoteData(quoteLookup, key, code, cash)},
+		{Text: "🔄 刷新", CallbackData: quoteRefreshData(quoteCompare, key, code, cash)},
+	}}}
+	return text, markup, nil
+}
+
+// quoteKeyboard 切换银行 / 切换币种 / 现汇现钞、刷新、对比
+func quoteKeyboard(key, code string, cash bool) *models.InlineKeyboardMarkup {
+	var rows [][]models.InlineKeyboardButton
+
+	var row []models.InlineKeyboardButton
+	for _, p := range bank.Providers() {
+		if p.Key() == key {
+			continue
+		}
+		row = append(row, models.InlineKeyboardButton{Text: p.Name(), CallbackData: quoteData(quoteLookup, p.Key(), code, cash)})
+		if len(row) == quoteBanksPerRow {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+
+	row = nil
+	for _, c := range quoteCurrencies {
+		if c == code {
+			continue
+		}
+		row = append(row, models.InlineKeyboardButton{Text: c, CallbackData: quoteData(quoteLookup, key, c, cash)})
+	}
+	rows = append(rows, row)
+
+	rows = append(rows, []models.InlineKeyboardButton{
+		{Text: "切换到" + quoteKind(!cash), CallbackData: quoteData(quoteLookup, key, code, !cash)},
+		{Text: "🔄 刷新", CallbackData: quoteRefreshData(quoteLookup, key, code, cash)},
+		{Text: "📊 对比", CallbackData: quoteData(quoteCompare, key, code, cash)},
+	})
+	return &models.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
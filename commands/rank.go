@@ -0,0 +1,256 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/shopspring/decimal"
+
+	"aki.telegram.bot.fxrate/bank"
+	"aki.telegram.bot.fxrate/tools"
+)
+
+// rankMetric 排序对比的指标
+type rankMetric struct {
+	Label string                                  // 指标名，如“现汇买入价”
+	Title string                                  // 结果标题，如“现汇买入最优排序”
+	Value func(q *bank.Quote) decimal.NullDecimal // 取值，统一按“每100外币”
+	Desc  bool                                    // true 时越高越优，否则越低越优
+}
+
+// fieldMetric 以某一项牌价为指标
+func fieldMetric(f bank.Field, desc bool) rankMetric {
+	return rankMetric{
+		Label: f.Label(),
+		Title: strings.TrimSuffix(f.Label(), "价") + "最优排序",
+		Value: func(q *bank.Quote) decimal.NullDecimal { return q.Per(f, 100) },
+		Desc:  desc,
+	}
+}
+
+// spreadMetric 以卖出价减买入价为指标，越小越好；任一缺失时不参与排序
+func spreadMetric(m bank.Method) rankMetric {
+	buy, sell := bank.SideField(true, m), bank.SideField(false, m)
+	return rankMetric{
+		Label: m.Label() + "买卖价差",
+		Title: m.Label() + "价差排序（从小到大）",
+		Value: func(q *bank.Quote) decimal.NullDecimal {
+			b, s := q.Per(buy, 100), q.Per(sell, 100)
+			if !b.Valid || !s.Valid {
+				return decimal.NullDecimal{}
+			}
+			return decimal.NewNullDecimal(s.Decimal.Sub(b.Decimal))
+		},
+	}
+}
+
+// rankCommands 排序对比命令：买入价越高越好（结汇多拿人民币），卖出价越低越好（购汇少花人民币），
+// 价差越小越好；中间价没有优劣，按从高到低列出。新增对比只需在这里加一项并在 command.go 里路由
+var rankCommands = map[string]rankMetric{
+	"xhmr": fieldMetric(bank.BuySpot, true),
+	"xhmc": fieldMetric(bank.SellSpot, false),
+	"xcmr": fieldMetric(bank.BuyCash, true),
+	"xcmc": fieldMetric(bank.SellCash, false),
+	"zjj": {
+		Label: bank.Middle.Label(),
+		Title: "中间价排序（从高到低）",
+		Value: func(q *bank.Quote) decimal.NullDecimal { return q.Per(bank.Middle, 100) },
+		Desc:  true,
+	},
+	"xhjc": spreadMetric(bank.Spot),
+	"xcjc": spreadMetric(bank.Cash),
+}
+
+func HandleXHMRCommand(ctx context.Context, b *bot.Bot, update *models.Update) {
+	handleRank(ctx, b, update, "xhmr")
+}
+
+func HandleXHMCCommand(ctx context.Context, b *bot.Bot, update *models.Update) {
+	handleRank(ctx, b, update, "xhmc")
+}
+
+func HandleXCMRCommand(ctx context.Context, b *bot.Bot, update *models.Update) {
+	handleRank(ctx, b, update, "xcmr")
+}
+
+func HandleXCMCCommand(ctx context.Context, b *bot.Bot, update *models.Update) {
+	handleRank(ctx, b, update, "xcmc")
+}
+
+func HandleZJJCommand(ctx context.Context, b *bot.Bot, update *models.Update) {
+	handleRank(ctx, b, update, "zjj")
+}
+
+func HandleXHJCCommand(ctx context.Context, b *bot.Bot, update *models.Update) {
+	handleRank(ctx, b, update, "xhjc")
+}
+
+func HandleXCJCCommand(ctx context.Context, b *bot.Bot, update *models.Update) {
+	handleRank(ctx, b, update, "xcjc")
+}
+
+// handleRank /<cmd> [币种] [数字|银行...]：并发拉取各来源，按 rankCommands[cmd] 排序
+func handleRank(ctx context.Context, b *bot.Bot, update *models.Update, cmd string) {
+	if update == nil || update.Message == nil {
+		return
+	}
+	metric := rankCommands[cmd]
+	chatID, threadID := update.Message.Chat.ID, update.Message.MessageThreadID
+
+	fields := strings.Fields(update.Message.Text)
+	if len(fields) < 2 {
+		tools.SendMessage(ctx, b, chatID, fmt.Sprintf("用法: /%[1]s [币种] [数字|银行...]，例如:\n/%[1]s hkd\n/%[1]s hkd 3\n/%[1]s hkd boc cmb", cmd), threadID, "")
+		return
+	}
+
+	if !resolveArgs(ctx, b, update, fields, 1) {
+		return
+	}
+	ccy := fields[1]
+
+	// 解析可选参数：TopN（数字）与指定银行列表
+	var topN int
+	var bankKeys []string
+	for _, t := range fields[2:] {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" {
+			continue
+		}
+		if n, err := strconv.Atoi(t); err == nil && n > 0 {
+			topN = n
+			continue
+		}
+		if _, ok := bank.Lookup(t); ok {
+			bankKeys = append(bankKeys, t)
+		}
+	}
+	bankKeys = dedup(bankKeys)
+	if len(bankKeys) == 0 {
+		for _, p := range bank.Providers() {
+			bankKeys = append(bankKeys, p.Key())
+		}
+	}
+
+	waitMsgID, _ := tools.SendMessage(ctx, b, chatID,
+		fmt.Sprintf("正在查询和比对 %s 的%s，请稍候…", ccy, metric.Label),
+		threadID, "")
+
+	results, timeoutKeys, missingKeys := fetchRanked(ctx, bankKeys, ccy, metric, cmd)
+	// 拿到结果（或确认没有结果）后先删除等待提示消息（忽略删除错误）
+	_ = tools.DeleteMessage(ctx, b, chatID, waitMsgID)
+
+	if len(results) == 0 {
+		tools.SendMessage(ctx, b, chatID, fmt.Sprintf("未找到该币种的%s，请尝试币种代码（如: USD/HKD）或中文名。", metric.Label), threadID, "")
+		// 若有超时，额外提醒
+		if len(timeoutKeys) > 0 {
+			tools.SendMessage(ctx, b, chatID, fmt.Sprintf("提醒：以下银行查询超时（>20s）：%s", strings.Join(mapBankNames(timeoutKeys), ", ")), threadID, "")
+		}
+		return
+	}
+
+	// 截取 Top N
+	if topN > 0 && topN < len(results) {
+		results = results[:topN]
+	}
+
+	// 货币展示名
+	currencyDesc := ccy
+	for _, r := range results {
+		if strings.TrimSpace(r.CurrencyDesc) != "" {
+			currencyDesc = r.CurrencyDesc
+			break
+		}
+	}
+
+	// 组装消息
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s — %s\n", metric.Title, currencyDesc))
+	for i, r := range results {
+		sb.WriteString(fmt.Sprintf("%d. %s: %s（发布时间: %s）\n", i+1, r.BankNameCN, r.Raw, r.ReleaseTime))
+	}
+	tools.SendMessage(ctx, b, chatID, sb.String(), threadID, "")
+	// 若有超时，额外提醒
+	if len(timeoutKeys) > 0 {
+		tools.SendMessage(ctx, b, chatID, fmt.Sprintf("提醒：以下银行查询超时（>20s）：%s", strings.Join(mapBankNames(timeoutKeys), ", ")), threadID, "")
+	}
+	// 若有未返回数据（非超时），提示可能为不支持该币种或接口异常
+	if len(missingKeys) > 0 {
+		tools.SendMessage(ctx, b, chatID, fmt.Sprintf("提示：以下银行未返回数据（可能不支持该币种或接口异常）：%s", strings.Join(mapBankNames(missingKeys), ", ")), threadID, "")
+	}
+}
+
+// fetchRanked 并发拉取 bankKeys 的指标值（每个银行一个 goroutine，单请求超时 20s）并按 metric 排序。
+// 返回排好序的结果、超时的银行，以及既未超时也没有数据的银行；
+// 找到币种但来源不提供该项报价（如银联只有参考汇率）的不算缺失。
+func fetchRanked(ctx context.Context, bankKeys []string, ccy string, metric rankMetric, tag string) (results []bankRate, timeoutKeys, missingKeys []string) {
+	resultsCh := make(chan *bankRate, len(bankKeys))
+	timeoutsCh := make(chan string, len(bankKeys))
+	skippedCh := make(chan string, len(bankKeys))
+	var wg sync.WaitGroup
+	for _, key := range bankKeys {
+		k := key
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctxFetch, cancel := context.WithTimeout(ctx, 20*time.Second)
+			defer cancel()
+			p, _ := bank.Lookup(k)
+			r, found := fetchProviderRate(ctxFetch, p, ccy, metric.Value, tag)
+			if found && r == nil {
+				skippedCh <- k
+				return
+			}
+			if r != nil {
+				resultsCh <- r
+			} else if ctxFetch.Err() == context.DeadlineExceeded {
+				timeoutsCh <- k
+				tools.LogError("%s: %s 查询超时（>20s）", tag, strings.Join(mapBankNames([]string{k}), ", "))
+			}
+		}()
+	}
+	wg.Wait()
+	close(resultsCh)
+	close(timeoutsCh)
+	close(skippedCh)
+
+	for r := range resultsCh {
+		results = append(results, *r)
+	}
+	for k := range timeoutsCh {
+		timeoutKeys = append(timeoutKeys, k)
+	}
+	want := make(map[string]struct{}, len(bankKeys))
+	for _, k := range bankKeys {
+		want[k] = struct{}{}
+	}
+	for _, r := range results {
+		delete(want, r.BankKey)
+	}
+	for _, tk := range timeoutKeys {
+		delete(want, tk)
+	}
+	for sk := range skippedCh {
+		delete(want, sk)
+	}
+	// 按传入顺序输出，免得提示里的银行顺序每次都不一样
+	for _, k := range bankKeys {
+		if _, ok := want[k]; ok {
+			missingKeys = append(missingKeys, k)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if metric.Desc {
+			return results[i].Val.GreaterThan(results[j].Val)
+		}
+		return results[i].Val.LessThan(results[j].Val)
+	})
+	return results, timeoutKeys, missingKeys
+}
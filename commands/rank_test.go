@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"aki.telegram.bot.fxrate/bank"
+)
+
+func price(s string) decimal.NullDecimal {
+	if s == "" {
+		return decimal.NullDecimal{}
+	}
+	return decimal.NewNullDecimal(decimal.RequireFromString(s))
+}
+
+func TestSpreadMetric(t *testing.T) {
+	tests := []struct {
+		name string
+		m    bank.Method
+		q    bank.Quote
+		want string // 空表示不参与排序
+	}{
+		{"现汇", bank.Spot, bank.Quote{Unit: 100, BuySpot: price("710.00"), SellSpot: price("713.00")}, "3"},
+		{"现钞", bank.Cash, bank.Quote{Unit: 100, BuySpot: price("710.00"), BuyCash: price("704.50"), SellSpot: price("713.00"), SellCash: price("713.00")}, "8.5"},
+		{"按每100外币折算", bank.Spot, bank.Quote{Unit: 1, BuySpot: price("7.1000"), SellSpot: price("7.1250")}, "2.5"},
+		{"缺买入价", bank.Spot, bank.Quote{Unit: 100, SellSpot: price("713.00")}, ""},
+		{"缺卖出价", bank.Spot, bank.Quote{Unit: 100, BuySpot: price("710.00")}, ""},
+		{"现钞缺价不借用现汇", bank.Cash, bank.Quote{Unit: 100, BuySpot: price("710.00"), SellSpot: price("713.00"), BuyCash: price("704.50")}, ""},
+	}
+	for _, tt := range tests {
+		got := spreadMetric(tt.m).Value(&tt.q)
+		if tt.want == "" {
+			if got.Valid {
+				t.Errorf("%s: spread = %s, want none", tt.name, got.Decimal)
+			}
+			continue
+		}
+		if !got.Valid || !got.Decimal.Equal(decimal.RequireFromString(tt.want)) {
+			t.Errorf("%s: spread = %v, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRankCommands(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		desc bool
+	}{
+		{"xhmr", true}, {"xhmc", false}, {"xcmr", true}, {"xcmc", false},
+		{"zjj", true}, {"xhjc", false}, {"xcjc", false},
+	}
+	for _, tt := range tests {
+		m, ok := rankCommands[tt.cmd]
+		if !ok {
+			t.Errorf("rankCommands[%q] missing", tt.cmd)
+			continue
+		}
+		if m.Desc != tt.desc || m.Label == "" || m.Title == "" || m.Value == nil {
+			t.Errorf("rankCommands[%q] = %+v", tt.cmd, m)
+		}
+	}
+}
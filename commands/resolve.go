@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"aki.telegram.bot.fxrate/currency"
+	"aki.telegram.bot.fxrate/tools"
+)
+
+// RetryPrefix 歧义候选按钮的回调前缀，其后是改写好的完整命令
+const RetryPrefix = "re:"
+
+// callbackDataLimit Telegram 回调数据的字节上限
+const callbackDataLimit = 64
+
+// resolveArgs 将 fields 中下标为 idx 的币种参数识别为 ISO 代码并原地改写；
+// 无法识别时回复提示，存在歧义时回复候选键盘，这两种情况返回 false，调用方直接结束即可。
+func resolveArgs(ctx context.Context, b *bot.Bot, update *models.Update, fields []string, idx ...int) bool {
+	for _, i := range idx {
+		if i >= len(fields) {
+			continue
+		}
+		c, err := currency.Resolve(fields[i])
+		if err == nil {
+			fields[i] = c.Code
+			continue
+		}
+		var amb *currency.AmbiguousError
+		if errors.As(err, &amb) {
+			sendCandidates(ctx, b, update, fields, i, amb)
+		} else {
+			tools.SendMessage(ctx, b, update.Message.Chat.ID,
+				fmt.Sprintf("未找到币种「%s」，请尝试币种代码（如: USD/HKD）或中文名。", html.EscapeString(fields[i])),
+				update.Message.MessageThreadID, "")
+		}
+		return false
+	}
+	return true
+}
+
+// sendCandidates 回复候选币种键盘，点击后以对应代码替换原参数重新执行命令
+func sendCandidates(ctx context.Context, b *bot.Bot, update *models.Update, fields []string, i int, amb *currency.AmbiguousError) {
+	// 在原始消息上替换，保留 cash/sell 等选项
+	orig := strings.Fields(update.Message.Text)
+	pos := -1
+	for j := 1; j < len(orig); j++ {
+		if orig[j] == fields[i] {
+			pos = j
+			break
+		}
+	}
+	var rows [][]models.InlineKeyboardButton
+	for _, c := range amb.Candidates {
+		args := append([]string(nil), fields...)
+		if pos >= 0 {
+			args = append([]string(nil), orig...)
+			args[pos] = c.Code
+		} else {
+			args[i] = c.Code
+		}
+		data := RetryPrefix + strings.Join(args, " ")
+		if len(data) > callbackDataLimit {
+			continue
+		}
+		rows = append(rows, []models.InlineKeyboardButton{{
+			Text:         fmt.Sprintf("%s %s", c.Code, c.NameCN),
+			CallbackData: data,
+		}})
+	}
+	if len(rows) == 0 {
+		tools.SendMessage(ctx, b, update.Message.Chat.ID,
+			fmt.Sprintf("「%s」可能指多个币种，请改用币种代码（如: USD/HKD）。", html.EscapeString(amb.Query)),
+			update.Message.MessageThreadID, "")
+		return
+	}
+	tools.SendMessageWithMarkup(ctx, b, update.Message.Chat.ID,
+		fmt.Sprintf("「%s」可能指以下币种，请选择：", html.EscapeString(amb.Query)),
+		update.Message.MessageThreadID, "",
+		&models.InlineKeyboardMarkup{InlineKeyboard: rows})
+}
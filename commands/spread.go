@@ -0,0 +1,115 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"github.com/shopspring/decimal"
+
+	"aki.telegram.bot.fxrate/bank"
+	"aki.telegram.bot.fxrate/currency"
+	"aki.telegram.bot.fxrate/money"
+	"aki.telegram.bot.fxrate/tools"
+)
+
+// defaultRoundTrip /spread 未给金额时按 1 万人民币计算往返成本
+var defaultRoundTrip = decimal.NewFromInt(10000)
+
+// spreadRow 某个来源的价差
+type spreadRow struct {
+	Name string
+	Abs  decimal.Decimal // 卖出价 - 买入价，每100外币
+	BP   decimal.Decimal // 相对中间价的基点
+	Cost decimal.Decimal // 往返损失的人民币
+}
+
+// HandleSpreadCommand /spread [币种] [金额] [cash]：各来源买卖价差排名与 CNY→外币→CNY 往返成本
+func HandleSpreadCommand(ctx context.Context, b *bot.Bot, update *models.Update) {
+	if update == nil || update.Message == nil {
+		return
+	}
+	chatID, threadID := update.Message.Chat.ID, update.Message.MessageThreadID
+
+	fields, opts := parseRateFlags(strings.Fields(update.Message.Text))
+	if len(fields) < 2 {
+		fields = append(fields, "USD")
+	}
+	if !resolveArgs(ctx, b, update, fields, 1) {
+		return
+	}
+	ccy := fields[1]
+	if currency.IsCNY(ccy) {
+		tools.SendMessage(ctx, b, chatID, "用法: /spread [币种] [金额] [cash]，例如:\n/spread usd\n/spread hkd 50000\n/spread jpy cash", threadID, "")
+		return
+	}
+	amount := defaultRoundTrip
+	if len(fields) >= 3 {
+		v, ok := ParseAmount(fields[2])
+		if !ok || !v.IsPositive() {
+			tools.SendMessage(ctx, b, chatID, "金额格式不正确，请输入正数，例如: 10000", threadID, "")
+			return
+		}
+		amount = v
+	}
+
+	quotes, timeoutKeys := fetchAllQuotes(ctx, ccy, "spread")
+	buyF, sellF := bank.SideField(true, opts.Method), bank.SideField(false, opts.Method)
+
+	var rows []spreadRow
+	for _, pq := range quotes {
+		buy, sell := pq.Quote.Per(buyF, 100), pq.Quote.Per(sellF, 100)
+		if !buy.Valid || !sell.Valid {
+			continue
+		}
+		mid := money.Mid(buy.Decimal, sell.Decimal)
+		abs := sell.Decimal.Sub(buy.Decimal)
+		// 往返两笔都按银行实际舍入走一遍，不用价差直接乘
+		out, err := bank.Convert(pq.Quote, "CNY", ccy, amount, bank.AutoSide, opts.Method, money.HalfUp)
+		if err != nil || out.Fallback {
+			continue
+		}
+		back, err := bank.Convert(pq.Quote, ccy, "CNY", out.Result, bank.AutoSide, opts.Method, money.HalfUp)
+		if err != nil || back.Fallback {
+			continue
+		}
+		rows = append(rows, spreadRow{
+			Name: pq.Provider.Name(),
+			Abs:  abs,
+			BP:   abs.Div(mid).Mul(decimal.NewFromInt(10000)),
+			Cost: amount.Sub(back.Result),
+		})
+	}
+	if len(rows) == 0 {
+		tools.SendMessage(ctx, b, chatID, fmt.Sprintf("没有银行同时提供该币种的%s买入价和卖出价。", opts.Method.Label()), threadID, "")
+		return
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].BP.LessThan(rows[j].BP) })
+
+	table := [][]string{{"银行", "价差", "bp", "%", "往返成本"}}
+	for _, r := range rows {
+		table = append(table, []string{
+			r.Name,
+			boardPrice(decimal.NewNullDecimal(r.Abs)),
+			r.BP.StringFixed(1),
+			r.BP.Div(decimal.NewFromInt(100)).StringFixed(3),
+			money.Format(r.Cost, "CNY"),
+		})
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("<b>%s买卖价差排名 — %s (%s)</b>\n", opts.Method.Label(), html.EscapeString(currency.Name(ccy)), ccy))
+	sb.WriteString("<pre>")
+	sb.WriteString(html.EscapeString(alignTable(table)))
+	sb.WriteString("</pre>\n")
+	sb.WriteString(fmt.Sprintf("价差为每100外币的卖出价减买入价，bp / %% 相对买卖中间价；\n往返成本: %s CNY 按卖出价换成 %s 再按买入价换回 CNY 的损失。",
+		money.Format(amount, "CNY"), ccy))
+	tools.SendMessage(ctx, b, chatID, sb.String(), threadID, "HTML")
+	if len(timeoutKeys) > 0 {
+		tools.SendMessage(ctx, b, chatID, fmt.Sprintf("提醒：以下银行查询超时（>20s）：%s", strings.Join(mapBankNames(timeoutKeys), ", ")), threadID, "")
+	}
+}
@@ -1,84 +1,63 @@
 package commands
 
 import (
+	"context"
 	"fmt"
-	"strconv"
 	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"aki.telegram.bot.fxrate/bank"
+	"aki.telegram.bot.fxrate/money"
+	"aki.telegram.bot.fxrate/tools"
 )
 
 type bankRate struct {
 	BankNameCN   string
 	BankKey      string
 	CurrencyDesc string
-	BuySpotVal   float64
-	BuySpotRaw   string
+	Val          decimal.Decimal // 指标值（每100外币）
+	Raw          string
 	ReleaseTime  string
 }
 
 // ParseAmount 解析输入金额，允许包含千分位逗号
-func ParseAmount(s string) (float64, bool) {
-	s = strings.TrimSpace(s)
-	if s == "" {
-		return 0, false
-	}
-	s = strings.ReplaceAll(s, ",", "")
-	v, err := strconv.ParseFloat(s, 64)
-	if err != nil {
-		return 0, false
-	}
-	return v, true
+func ParseAmount(s string) (decimal.Decimal, bool) {
+	return money.ParseAmount(s)
 }
 
-// ParseRate 解析牌价，过滤 "-" 或空
-func ParseRate(s string) (float64, bool) {
+// ParseRate 解析牌价，过滤 "-"、空或非正数
+func ParseRate(s string) (decimal.Decimal, bool) {
 	s = strings.TrimSpace(s)
 	if s == "" || s == "-" {
-		return 0, false
+		return decimal.Zero, false
 	}
-	s = strings.ReplaceAll(s, ",", "")
-	v, err := strconv.ParseFloat(s, 64)
-	if err != nil {
-		return 0, false
+	v, ok := money.ParseAmount(s)
+	if !ok || !v.IsPositive() {
+		return decimal.Zero, false
 	}
 	return v, true
 }
 
-// IsCNY 判断是否为人民币
-func IsCNY(code string) bool {
-	c := strings.ToLower(strings.TrimSpace(code))
-	c = strings.ReplaceAll(c, "_", "")
-	c = strings.ReplaceAll(c, "-", "")
-	c = strings.ReplaceAll(c, "/", "")
-	switch c {
-	case "cny", "rmb", "renminbi", "人民币":
-		return true
-	default:
-		return false
-	}
-}
-
-// UpperCurrency 规范化货币代码为大写（保留原有字符集）
-func UpperCurrency(code string) string { return strings.ToUpper(strings.TrimSpace(code)) }
-
 // FormatCNYToFX 构造 CNY -> 外币 的换算消息
-func FormatCNYToFX(bankName, toName, toCode string, amountCNY, outFX float64, label, rateStr, releaseTime string) string {
+func FormatCNYToFX(bankName, toName, toCode string, amountCNY, outFX decimal.Decimal, label, rateStr, releaseTime string) string {
 	return fmt.Sprintf(
 		"按%s牌价换算: %s -> %s\n\n"+
-			"%.2f CNY ≈ %.2f %s\n\n"+
+			"%s CNY ≈ %s %s\n\n"+
 			"使用牌价: %s %s\n"+
 			"发布时间: %s",
-		bankName, "CNY", toName, amountCNY, outFX, toCode, label, rateStr, releaseTime,
+		bankName, "CNY", toName, money.Format(amountCNY, "CNY"), money.Format(outFX, toCode), toCode, label, rateStr, releaseTime,
 	)
 }
 
 // FormatFXToCNY 构造 外币 -> CNY 的换算消息
-func FormatFXToCNY(bankName, fromName, fromCode string, amountFX, outCNY float64, label, rateStr, releaseTime string) string {
+func FormatFXToCNY(bankName, fromName, fromCode string, amountFX, outCNY decimal.Decimal, label, rateStr, releaseTime string) string {
 	return fmt.Sprintf(
 		"按%s牌价换算: %s -> %s\n\n"+
-			"%.2f %s ≈ %.2f CNY\n\n"+
+			"%s %s ≈ %s CNY\n\n"+
 			"使用牌价: %s %s\n"+
 			"发布时间: %s",
-		bankName, fromName, "CNY", amountFX, fromCode, outCNY, label, rateStr, releaseTime,
+		bankName, fromName, "CNY", money.Format(amountFX, fromCode), fromCode, money.Format(outCNY, "CNY"), label, rateStr, releaseTime,
 	)
 }
 
@@ -101,21 +80,38 @@ func mapBankNames(keys []string) []string {
 	if len(keys) == 0 {
 		return nil
 	}
-	m := map[string]string{
-		"boc":   "中国银行",
-		"cib":   "兴业银行",
-		"cmb":   "招商银行",
-		"hy":    "寰宇人生",
-		"cgb":   "广发银行",
-		"citic": "中信银行",
-	}
 	out := make([]string, 0, len(keys))
 	for _, k := range keys {
-		if v, ok := m[k]; ok {
-			out = append(out, v)
+		if p, ok := bank.Lookup(k); ok {
+			out = append(out, p.Name())
 		} else {
 			out = append(out, k)
 		}
 	}
 	return out
-}
\ No newline at end of file
+}
+
+// fetchProviderRate 从来源拉取 ccy 的一行牌价，用 value 取出指标值（统一按“每100外币”）。
+// found 表示找到了该币种；found 为 true 但 r 为 nil 时说明来源不提供这一项报价。
+func fetchProviderRate(ctx context.Context, p bank.Provider, ccy string, value func(*bank.Quote) decimal.NullDecimal, tag string) (r *bankRate, found bool) {
+	q, ok, err := bank.FetchQuote(ctx, p, ccy)
+	if err != nil {
+		tools.LogError("%s: %s 获取失败: %v", tag, p.Name(), err)
+		return nil, false
+	}
+	if !ok || q == nil {
+		return nil, false
+	}
+	v := value(q)
+	if !v.Valid {
+		return nil, true
+	}
+	return &bankRate{
+		BankNameCN:   p.Name(),
+		BankKey:      p.Key(),
+		CurrencyDesc: q.Name,
+		Val:          v.Decimal,
+		Raw:          money.FormatRate(v.Decimal),
+		ReleaseTime:  bank.FormatTime(q.ReleaseTime),
+	}, true
+}
@@ -0,0 +1,98 @@
+package currency
+
+import (
+	"strings"
+)
+
+// Currency 币种元数据
+type Currency struct {
+	Code    string   // ISO 4217 字母代码，如 "USD"
+	Numeric string   // ISO 4217 数字代码，如 "840"
+	Minor   int32    // 最小货币单位的小数位数（JPY 0、KWD 3）
+	NameCN  string   // 标准中文名
+	NameEN  string   // 英文名
+	Aliases []string // 各银行写法与俗称
+}
+
+var (
+	byCode = map[string]*Currency{}
+	index  = map[string]*Currency{} // 规范化后的 代码/数字代码/名称/别名 -> 币种
+)
+
+func init() {
+	for i := range currencies {
+		c := &currencies[i]
+		byCode[c.Code] = c
+		keys := append([]string{c.Code, c.Numeric, c.NameCN, c.NameEN}, c.Aliases...)
+		for _, k := range keys {
+			k = normalize(k)
+			if k == "" {
+				continue
+			}
+			if prev, dup := index[k]; dup && prev != c {
+				panic("currency: alias " + k + " used by both " + prev.Code + " and " + c.Code)
+			}
+			index[k] = c
+		}
+	}
+}
+
+// normalize 去掉空白与常见分隔符并转小写
+func normalize(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	return strings.NewReplacer(" ", "", "_", "", "-", "", "/", "", "　", "").Replace(s)
+}
+
+// Get 按 ISO 代码取币种
+func Get(code string) (*Currency, bool) {
+	c, ok := byCode[strings.ToUpper(strings.TrimSpace(code))]
+	return c, ok
+}
+
+// Lookup 按 代码/数字代码/中英文名/别名 精确识别币种
+func Lookup(q string) (*Currency, bool) {
+	c, ok := index[normalize(q)]
+	return c, ok
+}
+
+// All 全部币种，按表中顺序
+func All() []*Currency {
+	out := make([]*Currency, 0, len(currencies))
+	for i := range currencies {
+		out = append(out, &currencies[i])
+	}
+	return out
+}
+
+// Code 识别 q 并返回 ISO 代码，识别不出返回空串
+func Code(q string) string {
+	if c, ok := Lookup(q); ok {
+		return c.Code
+	}
+	return ""
+}
+
+// Normalize 识别 q 并返回 ISO 代码，识别不出时原样转大写
+func Normalize(q string) string {
+	if c, ok := Lookup(q); ok {
+		return c.Code
+	}
+	return strings.ToUpper(strings.TrimSpace(q))
+}
+
+// Name 返回币种中文名，未知币种返回代码本身
+func Name(code string) string {
+	if c, ok := Get(code); ok {
+		return c.NameCN
+	}
+	if c, ok := Lookup(code); ok {
+		return c.NameCN
+	}
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// IsCNY 判断是否为人民币
+func IsCNY(q string) bool {
+	c, ok := Lookup(q)
+	return ok && c.Code == "CNY"
+}
@@ -0,0 +1,56 @@
+package currency
+
+// currencies 币种表
+// Aliases 收录各家银行页面上的写法（中行“港币”、广发“港元”、中信“韩元”“坚戈”、
+// 中行“卢布”……）以及群里常见的俗称，新增银行时如遇新写法直接补在这里。
+var currencies = []Currency{
+	{Code: "CNY", Numeric: "156", Minor: 2, NameCN: "人民币", NameEN: "Chinese Yuan Renminbi", Aliases: []string{"rmb", "renminbi", "yuan", "软妹币", "人民幣"}},
+	{Code: "USD", Numeric: "840", Minor: 2, NameCN: "美元", NameEN: "US Dollar", Aliases: []string{"美金", "美刀", "刀", "美币", "buck", "bucks", "greenback"}},
+	{Code: "HKD", Numeric: "344", Minor: 2, NameCN: "港元", NameEN: "Hong Kong Dollar", Aliases: []string{"港币", "港幣", "港纸", "港紙"}},
+	{Code: "EUR", Numeric: "978", Minor: 2, NameCN: "欧元", NameEN: "Euro", Aliases: []string{"歐元", "euro", "euros"}},
+	{Code: "GBP", Numeric: "826", Minor: 2, NameCN: "英镑", NameEN: "Pound Sterling", Aliases: []string{"英鎊", "quid", "sterling"}},
+	{Code: "JPY", Numeric: "392", Minor: 0, NameCN: "日元", NameEN: "Japanese Yen", Aliases: []string{"日圆", "日圓", "日币", "日幣", "円", "日円", "yen"}},
+	{Code: "AUD", Numeric: "036", Minor: 2, NameCN: "澳大利亚元", NameEN: "Australian Dollar", Aliases: []string{"澳元", "澳币", "澳幣", "澳洲元"}},
+	{Code: "CAD", Numeric: "124", Minor: 2, NameCN: "加拿大元", NameEN: "Canadian Dollar", Aliases: []string{"加元", "加币", "加幣"}},
+	{Code: "SGD", Numeric: "702", Minor: 2, NameCN: "新加坡元", NameEN: "Singapore Dollar", Aliases: []string{"新元", "新币", "新幣", "坡币", "新加坡币"}},
+	{Code: "NZD", Numeric: "554", Minor: 2, NameCN: "新西兰元", NameEN: "New Zealand Dollar", Aliases: []string{"纽元", "纽币", "新西兰币", "紐元"}},
+	{Code: "CHF", Numeric: "756", Minor: 2, NameCN: "瑞士法郎", NameEN: "Swiss Franc", Aliases: []string{"瑞郎", "法郎"}},
+	{Code: "THB", Numeric: "764", Minor: 2, NameCN: "泰国铢", NameEN: "Thai Baht", Aliases: []string{"泰铢", "泰币", "泰銖", "baht"}},
+	{Code: "TWD", Numeric: "901", Minor: 2, NameCN: "新台币", NameEN: "New Taiwan Dollar", Aliases: []string{"台币", "新台幣", "台幣", "ntd"}},
+	{Code: "KRW", Numeric: "410", Minor: 0, NameCN: "韩国元", NameEN: "South Korean Won", Aliases: []string{"韩元", "韩币", "韓元", "韓幣", "won"}},
+	{Code: "PHP", Numeric: "608", Minor: 2, NameCN: "菲律宾比索", NameEN: "Philippine Peso", Aliases: []string{"菲律宾披索", "菲币", "菲律賓比索"}},
+	{Code: "IDR", Numeric: "360", Minor: 2, NameCN: "印尼卢比", NameEN: "Indonesian Rupiah", Aliases: []string{"印度尼西亚卢比", "印尼盾", "rupiah"}},
+	{Code: "INR", Numeric: "356", Minor: 2, NameCN: "印度卢比", NameEN: "Indian Rupee", Aliases: []string{"印度盧比"}},
+	{Code: "RUB", Numeric: "643", Minor: 2, NameCN: "俄罗斯卢布", NameEN: "Russian Ruble", Aliases: []string{"卢布", "盧布", "ruble", "rouble"}},
+	{Code: "ZAR", Numeric: "710", Minor: 2, NameCN: "南非兰特", NameEN: "South African Rand", Aliases: []string{"兰特", "rand"}},
+	{Code: "AED", Numeric: "784", Minor: 2, NameCN: "阿联酋迪拉姆", NameEN: "UAE Dirham", Aliases: []string{"阿联酋迪尔汗", "迪拉姆"}},
+	{Code: "SAR", Numeric: "682", Minor: 2, NameCN: "沙特里亚尔", NameEN: "Saudi Riyal", Aliases: []string{"沙特阿拉伯里亚尔"}},
+	{Code: "QAR", Numeric: "634", Minor: 2, NameCN: "卡塔尔里亚尔", NameEN: "Qatari Riyal"},
+	{Code: "OMR", Numeric: "512", Minor: 3, NameCN: "阿曼里亚尔", NameEN: "Omani Rial"},
+	{Code: "KWD", Numeric: "414", Minor: 3, NameCN: "科威特第纳尔", NameEN: "Kuwaiti Dinar"},
+	{Code: "BHD", Numeric: "048", Minor: 3, NameCN: "巴林第纳尔", NameEN: "Bahraini Dinar"},
+	{Code: "JOD", Numeric: "400", Minor: 3, NameCN: "约旦第纳尔", NameEN: "Jordanian Dinar"},
+	{Code: "HUF", Numeric: "348", Minor: 2, NameCN: "匈牙利福林", NameEN: "Hungarian Forint", Aliases: []string{"福林", "forint"}},
+	{Code: "CZK", Numeric: "203", Minor: 2, NameCN: "捷克克朗", NameEN: "Czech Koruna"},
+	{Code: "PLN", Numeric: "985", Minor: 2, NameCN: "波兰兹罗提", NameEN: "Polish Zloty", Aliases: []string{"兹罗提", "zloty"}},
+	{Code: "SEK", Numeric: "752", Minor: 2, NameCN: "瑞典克朗", NameEN: "Swedish Krona"},
+	{Code: "DKK", Numeric: "208", Minor: 2, NameCN: "丹麦克朗", NameEN: "Danish Krone"},
+	{Code: "NOK", Numeric: "578", Minor: 2, NameCN: "挪威克朗", NameEN: "Norwegian Krone"},
+	{Code: "MXN", Numeric: "484", Minor: 2, NameCN: "墨西哥比索", NameEN: "Mexican Peso"},
+	{Code: "BRL", Numeric: "986", Minor: 2, NameCN: "巴西雷亚尔", NameEN: "Brazilian Real", Aliases: []string{"巴西里亚尔"}},
+	{Code: "ILS", Numeric: "376", Minor: 2, NameCN: "以色列新谢克尔", NameEN: "Israeli New Shekel", Aliases: []string{"以色列谢克尔", "谢克尔", "shekel"}},
+	{Code: "TRY", Numeric: "949", Minor: 2, NameCN: "土耳其里拉", NameEN: "Turkish Lira", Aliases: []string{"里拉", "lira"}},
+	{Code: "EGP", Numeric: "818", Minor: 2, NameCN: "埃及镑", NameEN: "Egyptian Pound"},
+	{Code: "VND", Numeric: "704", Minor: 0, NameCN: "越南盾", NameEN: "Vietnamese Dong", Aliases: []string{"dong"}},
+	{Code: "MYR", Numeric: "458", Minor: 2, NameCN: "马来西亚林吉特", NameEN: "Malaysian Ringgit", Aliases: []string{"林吉特", "马币", "馬幣", "ringgit"}},
+	{Code: "BND", Numeric: "096", Minor: 2, NameCN: "文莱元", NameEN: "Brunei Dollar", Aliases: []string{"文莱币"}},
+	{Code: "MOP", Numeric: "446", Minor: 2, NameCN: "澳门元", NameEN: "Macanese Pataca", Aliases: []string{"澳门币", "澳門幣", "葡币", "pataca"}},
+	{Code: "KHR", Numeric: "116", Minor: 2, NameCN: "柬埔寨瑞尔", NameEN: "Cambodian Riel", Aliases: []string{"瑞尔"}},
+	{Code: "LAK", Numeric: "418", Minor: 2, NameCN: "老挝基普", NameEN: "Lao Kip", Aliases: []string{"基普"}},
+	{Code: "MMK", Numeric: "104", Minor: 2, NameCN: "缅甸元", NameEN: "Myanmar Kyat", Aliases: []string{"缅元", "kyat"}},
+	{Code: "NPR", Numeric: "524", Minor: 2, NameCN: "尼泊尔卢比", NameEN: "Nepalese Rupee"},
+	{Code: "PKR", Numeric: "586", Minor: 2, NameCN: "巴基斯坦卢比", NameEN: "Pakistani Rupee"},
+	{Code: "LKR", Numeric: "144", Minor: 2, NameCN: "斯里兰卡卢比", NameEN: "Sri Lankan Rupee"},
+	{Code: "MNT", Numeric: "496", Minor: 2, NameCN: "蒙古图格里克", NameEN: "Mongolian Tugrik", Aliases: []string{"图格里克"}},
+	{Code: "KZT", Numeric: "398", Minor: 2, NameCN: "哈萨克斯坦坚戈", NameEN: "Kazakhstani Tenge", Aliases: []string{"坚戈", "哈萨克坚戈", "tenge"}},
+}
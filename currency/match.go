@@ -0,0 +1,125 @@
+package currency
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrUnknown 无法识别的币种
+var ErrUnknown = errors.New("currency: unknown currency")
+
+// AmbiguousError 模糊匹配命中多个得分相近的币种
+type AmbiguousError struct {
+	Query      string
+	Candidates []*Currency // 按得分从高到低
+}
+
+func (e *AmbiguousError) Error() string {
+	codes := make([]string, 0, len(e.Candidates))
+	for _, c := range e.Candidates {
+		codes = append(codes, c.Code)
+	}
+	return fmt.Sprintf("currency: %q is ambiguous (%s)", e.Query, strings.Join(codes, ", "))
+}
+
+const (
+	// ambiguityMargin 第一名领先第二名不足该分数时视为歧义
+	ambiguityMargin = 10
+	// maxCandidates 歧义时最多返回的候选数
+	maxCandidates = 8
+)
+
+// Resolve 识别币种：先精确匹配 代码/数字代码/名称/别名，
+// 否则对所有名称打分做模糊匹配；结果唯一时返回币种，
+// 无结果返回 ErrUnknown，多个候选得分接近时返回 *AmbiguousError。
+func Resolve(q string) (*Currency, error) {
+	if c, ok := Lookup(q); ok {
+		return c, nil
+	}
+	nq := normalize(q)
+	if nq == "" || (isASCII(nq) && len(nq) < 2) {
+		return nil, ErrUnknown
+	}
+	// 两个字母太短，只拿来比对代码前缀（us -> USD），免得命中一堆英文名
+	codeOnly := isASCII(nq) && len(nq) < 3
+
+	type scored struct {
+		c     *Currency
+		score int
+		order int
+	}
+	var hits []scored
+	for i := range currencies {
+		c := &currencies[i]
+		best := 0
+		keys := keysOf(c)
+		if codeOnly {
+			keys = keys[:1]
+		}
+		for _, k := range keys {
+			if s := matchScore(normalize(k), nq); s > best {
+				best = s
+			}
+		}
+		if best > 0 {
+			hits = append(hits, scored{c: c, score: best, order: i})
+		}
+	}
+	if len(hits) == 0 {
+		return nil, ErrUnknown
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].score != hits[j].score {
+			return hits[i].score > hits[j].score
+		}
+		return hits[i].order < hits[j].order
+	})
+	if len(hits) == 1 || hits[0].score-hits[1].score >= ambiguityMargin {
+		return hits[0].c, nil
+	}
+
+	err := &AmbiguousError{Query: q}
+	// 只列出与第一名接近的，明显更差的命中不当作候选
+	for _, h := range hits {
+		if len(err.Candidates) == maxCandidates || hits[0].score-h.score >= ambiguityMargin {
+			break
+		}
+		err.Candidates = append(err.Candidates, h.c)
+	}
+	return nil, err
+}
+
+func keysOf(c *Currency) []string {
+	return append([]string{c.Code, c.NameCN, c.NameEN}, c.Aliases...)
+}
+
+// matchScore 对单个名称打分，0 表示不匹配：
+// 前缀 > 包含 > 反向包含（如“美元现汇”包含“美元”），差的字数越少分越高
+func matchScore(key, q string) int {
+	if key == "" {
+		return 0
+	}
+	kl, ql := utf8.RuneCountInString(key), utf8.RuneCountInString(q)
+	switch {
+	case strings.HasPrefix(key, q):
+		return 90 - (kl - ql)
+	case strings.Contains(key, q):
+		return 70 - (kl - ql)
+	case kl >= 2 && strings.Contains(q, key):
+		return 50 - (ql - kl)
+	default:
+		return 0
+	}
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
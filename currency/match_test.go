@@ -0,0 +1,113 @@
+package currency
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		q    string
+		want string
+	}{
+		{"usd", "USD"},
+		{"USD", "USD"},
+		{"840", "USD"},
+		{"美元", "USD"},
+		{"美金", "USD"},
+		{"us", "USD"},   // 两个字母只比对代码前缀
+		{"美元现汇", "USD"}, // 反向包含
+		{"港", "HKD"},    // 前缀
+		{"港币", "HKD"},
+		{"日元", "JPY"},
+		{"yen", "JPY"},
+		{"euro", "EUR"},
+		{"pound", "GBP"},
+		{"英镑", "GBP"},
+	}
+	for _, tt := range tests {
+		c, err := Resolve(tt.q)
+		if err != nil {
+			t.Errorf("Resolve(%q) error: %v", tt.q, err)
+			continue
+		}
+		if c.Code != tt.want {
+			t.Errorf("Resolve(%q) = %s, want %s", tt.q, c.Code, tt.want)
+		}
+	}
+}
+
+func TestResolveUnknown(t *testing.T) {
+	for _, q := range []string{"", "u", "xx", "不是币种"} {
+		if c, err := Resolve(q); !errors.Is(err, ErrUnknown) {
+			t.Errorf("Resolve(%q) = %v, %v, want ErrUnknown", q, c, err)
+		}
+	}
+}
+
+func TestResolveAmbiguous(t *testing.T) {
+	tests := []struct {
+		q       string
+		first   string
+		include []string
+		exclude []string // 得分落后第一名太多，不应作为候选
+	}{
+		{"dollar", "USD", []string{"HKD", "CAD", "AUD"}, nil},
+		{"元", "USD", []string{"HKD", "JPY"}, nil},
+		{"澳", "AUD", []string{"MOP"}, nil},
+		{"新", "SGD", []string{"TWD", "NZD"}, []string{"ILS"}}, // 新加坡元 是前缀，以色列新谢克尔 只是包含
+	}
+	for _, tt := range tests {
+		_, err := Resolve(tt.q)
+		var amb *AmbiguousError
+		if !errors.As(err, &amb) {
+			t.Errorf("Resolve(%q) error = %v, want *AmbiguousError", tt.q, err)
+			continue
+		}
+		if amb.Query != tt.q {
+			t.Errorf("Resolve(%q) Query = %q", tt.q, amb.Query)
+		}
+		if len(amb.Candidates) > maxCandidates {
+			t.Errorf("Resolve(%q) %d candidates, want at most %d", tt.q, len(amb.Candidates), maxCandidates)
+		}
+		if amb.Candidates[0].Code != tt.first {
+			t.Errorf("Resolve(%q) first candidate = %s, want %s", tt.q, amb.Candidates[0].Code, tt.first)
+		}
+		for _, code := range tt.include {
+			found := false
+			for _, c := range amb.Candidates {
+				found = found || c.Code == code
+			}
+			if !found {
+				t.Errorf("Resolve(%q) candidates missing %s", tt.q, code)
+			}
+		}
+		for _, c := range amb.Candidates {
+			for _, code := range tt.exclude {
+				if c.Code == code {
+					t.Errorf("Resolve(%q) candidates include low-score %s", tt.q, code)
+				}
+			}
+		}
+	}
+}
+
+func TestMatchScore(t *testing.T) {
+	tests := []struct {
+		key, q string
+		want   int
+	}{
+		{"美元", "美", 89},    // 前缀，差 1 字
+		{"港币", "港币", 90},   // 完全相同按前缀算
+		{"新台币", "台币", 69},  // 包含，差 1 字
+		{"美元", "美元现汇", 48}, // 反向包含，多 2 字
+		{"元", "美元", 0},     // 单字名称不做反向包含
+		{"", "usd", 0},
+		{"欧元", "英镑", 0},
+	}
+	for _, tt := range tests {
+		if got := matchScore(tt.key, tt.q); got != tt.want {
+			t.Errorf("matchScore(%q, %q) = %d, want %d", tt.key, tt.q, got, tt.want)
+		}
+	}
+}
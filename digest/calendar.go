@@ -0,0 +1,72 @@
+package digest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"aki.telegram.bot.fxrate/bank"
+)
+
+// Calendar 工作日历：默认周一至周五为工作日，可配置法定节假日与调休上班日
+type Calendar struct {
+	Holidays map[string]bool // 放假的日期（含落在工作日的节假日）
+	Workdays map[string]bool // 调休上班的周末
+}
+
+// calendarFile 日历文件格式，日期均为 2006-01-02
+type calendarFile struct {
+	Holidays []string `json:"holidays"`
+	Workdays []string `json:"workdays"`
+}
+
+// IsWorkday t 所在的 Asia/Shanghai 日期是否为工作日
+func (c *Calendar) IsWorkday(t time.Time) bool {
+	t = t.In(bank.Shanghai)
+	day := t.Format(time.DateOnly)
+	if c != nil {
+		if c.Workdays[day] {
+			return true
+		}
+		if c.Holidays[day] {
+			return false
+		}
+	}
+	return t.Weekday() != time.Saturday && t.Weekday() != time.Sunday
+}
+
+// LoadCalendar 从 JSON 文件加载日历，path 为空时返回只区分周末的默认日历。
+// 文件示例（可按年追加）：
+//
+//	{"holidays": ["2026-10-01", "2026-10-02", "2026-10-05"],
+//	 "workdays": ["2026-09-27", "2026-10-10"]}
+func LoadCalendar(path string) (*Calendar, error) {
+	c := &Calendar{Holidays: map[string]bool{}, Workdays: map[string]bool{}}
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return c, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c, err
+	}
+	var f calendarFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return c, fmt.Errorf("digest: parse %s: %w", path, err)
+	}
+	for _, list := range []struct {
+		days []string
+		set  map[string]bool
+	}{{f.Holidays, c.Holidays}, {f.Workdays, c.Workdays}} {
+		for _, d := range list.days {
+			t, err := time.ParseInLocation(time.DateOnly, strings.TrimSpace(d), bank.Shanghai)
+			if err != nil {
+				return c, fmt.Errorf("digest: parse %s: bad date %q", path, d)
+			}
+			list.set[t.Format(time.DateOnly)] = true
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,81 @@
+package digest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"aki.telegram.bot.fxrate/bank"
+)
+
+// at Asia/Shanghai 时间 2026-mm-dd hh:mi
+func at(month time.Month, day, hour, min int) time.Time {
+	return time.Date(2026, month, day, hour, min, 0, 0, bank.Shanghai)
+}
+
+// national 2026 年国庆：10 月 1、2、5 日放假，9 月 27 日（周日）与 10 月 10 日（周六）调休上班
+func national() *Calendar {
+	return &Calendar{
+		Holidays: map[string]bool{"2026-10-01": true, "2026-10-02": true, "2026-10-05": true},
+		Workdays: map[string]bool{"2026-09-27": true, "2026-10-10": true},
+	}
+}
+
+func TestIsWorkday(t *testing.T) {
+	cal := national()
+	tests := []struct {
+		name string
+		cal  *Calendar
+		t    time.Time
+		want bool
+	}{
+		{"普通周五", cal, at(10, 16, 9, 0), true},
+		{"普通周六", cal, at(10, 17, 9, 0), false},
+		{"普通周日", cal, at(10, 18, 9, 0), false},
+		{"落在周四的节假日", cal, at(10, 1, 9, 0), false},
+		{"落在周一的节假日", cal, at(10, 5, 9, 0), false},
+		{"调休上班的周日", cal, at(9, 27, 9, 0), true},
+		{"调休上班的周六", cal, at(10, 10, 9, 0), true},
+		{"按上海日期判断", cal, at(10, 17, 1, 0).UTC(), false}, // UTC 下仍是周五
+		{"nil 日历只看周末", nil, at(10, 1, 9, 0), true},
+		{"nil 日历的周六", nil, at(10, 10, 9, 0), false},
+	}
+	for _, tt := range tests {
+		if got := tt.cal.IsWorkday(tt.t); got != tt.want {
+			t.Errorf("%s: IsWorkday(%s) = %v, want %v", tt.name, tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestLoadCalendar(t *testing.T) {
+	c, err := LoadCalendar(" ")
+	if err != nil || len(c.Holidays) != 0 || len(c.Workdays) != 0 {
+		t.Errorf("LoadCalendar(empty) = %+v, %v", c, err)
+	}
+
+	dir := t.TempDir()
+	if _, err := LoadCalendar(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("LoadCalendar(missing file) succeeded")
+	}
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	for _, data := range []string{`["2026-10-01"]`, `{"holidays": ["2026/10/01"]}`, `{"workdays": ["2026-02-30"]}`} {
+		if _, err := LoadCalendar(write("bad.json", data)); err == nil {
+			t.Errorf("LoadCalendar(%s) succeeded", data)
+		}
+	}
+
+	c, err = LoadCalendar(write("ok.json", `{"holidays": ["2026-10-01", " 2026-10-05 "], "workdays": ["2026-10-10"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Holidays["2026-10-05"] || c.IsWorkday(at(10, 1, 9, 0)) || !c.IsWorkday(at(10, 10, 9, 0)) {
+		t.Errorf("calendar = %+v", c)
+	}
+}
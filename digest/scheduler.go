@@ -0,0 +1,80 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"aki.telegram.bot.fxrate/bank"
+	"aki.telegram.bot.fxrate/tools"
+)
+
+// CatchUp 错过发送时间（如进程重启）后仍补发的时长，超过则这一期不再发送
+const CatchUp = 2 * time.Hour
+
+// Sender 发送一条订阅的汇总；返回 error 时稍后重试，包装了 ErrPermanent 的则这一期不再重试
+type Sender func(ctx context.Context, sub *Subscription) error
+
+// ErrPermanent 重试也不会成功的发送失败（如机器人被移出群、会话已删除）
+var ErrPermanent = errors.New("digest: permanent send failure")
+
+// Due 订阅在 now 时应发送的那一期（计划发送的日期）。
+// 当天的还没到点时看前一天的，以便跨零点补发前一天深夜错过的那一期。
+func (c *Calendar) Due(sub *Subscription, now time.Time) (day string, ok bool) {
+	now = now.In(bank.Shanghai)
+	for _, d := range []int{0, -1} {
+		at := time.Date(now.Year(), now.Month(), now.Day()+d, sub.Minute/60, sub.Minute%60, 0, 0, bank.Shanghai)
+		if now.Before(at) {
+			continue
+		}
+		day = at.Format(time.DateOnly)
+		if now.Sub(at) > CatchUp || sub.LastSent >= day {
+			return "", false
+		}
+		if !sub.Everyday && !c.IsWorkday(at) {
+			return "", false
+		}
+		return day, true
+	}
+	return "", false
+}
+
+// Run 每分钟检查一次到点的订阅，直到 ctx 结束。发送日期记在订阅里，重启后不会重复发送。
+func Run(ctx context.Context, s *Store, cal *Calendar, send Sender) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		sendDue(ctx, s, cal, send, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func sendDue(ctx context.Context, s *Store, cal *Calendar, send Sender, now time.Time) {
+	subs, err := s.All()
+	if err != nil {
+		tools.LogError("digest: 读取订阅失败: %v", err)
+		return
+	}
+	for i := range subs {
+		sub := &subs[i]
+		day, ok := cal.Due(sub, now)
+		if !ok {
+			continue
+		}
+		if err := send(ctx, sub); err != nil {
+			tools.LogError("digest: #%d 发送失败: %v", sub.ID, err)
+			if !errors.Is(err, ErrPermanent) {
+				continue
+			}
+			// 永久失败也记为这一期已处理，不再每分钟重试
+		}
+		sub.LastSent = day
+		if err := s.Update(sub); err != nil && !errors.Is(err, ErrNotFound) {
+			tools.LogError("digest: #%d 保存失败: %v", sub.ID, err)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestDue(t *testing.T) {
+	cal := national()
+	const morning, night = 9*60 + 30, 23*60 + 30
+	tests := []struct {
+		name     string
+		minute   int
+		everyday bool
+		lastSent string
+		now      time.Time
+		want     string // 应发送的那一期，空表示不发送
+	}{
+		{"未到点", morning, false, "", at(10, 16, 9, 29), ""},
+		{"恰好到点", morning, false, "", at(10, 16, 9, 30), "2026-10-16"},
+		{"补发窗口内", morning, false, "", at(10, 16, 11, 0), "2026-10-16"},
+		{"恰好错过 2 小时仍补发", morning, false, "", at(10, 16, 11, 30), "2026-10-16"},
+		{"超过补发窗口", morning, false, "", at(10, 16, 11, 31), ""},
+		{"当天已发送", morning, false, "2026-10-16", at(10, 16, 10, 0), ""},
+		{"前一天发送过", morning, false, "2026-10-15", at(10, 16, 10, 0), "2026-10-16"},
+		{"周末不发送", morning, false, "", at(10, 17, 9, 30), ""},
+		{"每天发送的周末", morning, true, "", at(10, 17, 9, 30), "2026-10-17"},
+		{"节假日不发送", morning, false, "", at(10, 1, 9, 30), ""},
+		{"每天发送的节假日", morning, true, "", at(10, 1, 9, 30), "2026-10-01"},
+		{"调休上班的周六", morning, false, "", at(10, 10, 9, 30), "2026-10-10"},
+
+		{"跨零点补发前一天的", night, false, "", at(10, 16, 0, 30), "2026-10-15"},
+		{"跨零点恰好 2 小时", night, false, "", at(10, 16, 1, 30), "2026-10-15"},
+		{"跨零点超过补发窗口", night, false, "", at(10, 16, 1, 31), ""},
+		{"前一天的已发送", night, false, "2026-10-15", at(10, 16, 0, 30), ""},
+		{"当天到点", night, false, "2026-10-15", at(10, 16, 23, 40), "2026-10-16"},
+		{"按计划日期判断工作日：周五那期在周六补发", night, false, "", at(10, 17, 0, 30), "2026-10-16"},
+		{"按计划日期判断工作日：周日那期不补发", night, false, "", at(10, 19, 0, 30), ""},
+		{"按计划日期判断工作日：节假日前一天那期照常补发", night, false, "", at(10, 1, 0, 30), "2026-09-30"},
+	}
+	for _, tt := range tests {
+		sub := &Subscription{Minute: tt.minute, Everyday: tt.everyday, LastSent: tt.lastSent}
+		day, ok := cal.Due(sub, tt.now)
+		if ok != (tt.want != "") || day != tt.want {
+			t.Errorf("%s: Due(%s, %s) = %q, %v, want %q", tt.name, sub.Clock(), tt.now.Format(time.DateTime), day, ok, tt.want)
+		}
+	}
+}
+
+func TestSendDue(t *testing.T) {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "digest.db"), 0o600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	s, err := New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 10 月 16 日 00:30 检查：23:30 的订阅补发 15 日那一期，09:30 的还没到点
+	results := map[int64]error{
+		1: nil,
+		2: errors.New("timeout"),
+		3: fmt.Errorf("%w: forbidden", ErrPermanent),
+	}
+	for chat := range results {
+		if err := s.Add(&Subscription{ChatID: chat, Minute: 23*60 + 30}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Add(&Subscription{ChatID: 4, Minute: 9*60 + 30}); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := map[int64]int{}
+	send := func(_ context.Context, sub *Subscription) error {
+		sent[sub.ChatID]++
+		return results[sub.ChatID]
+	}
+	now := at(10, 16, 0, 30)
+	sendDue(context.Background(), s, national(), send, now)
+	sendDue(context.Background(), s, national(), send, now.Add(time.Minute))
+
+	tests := []struct {
+		chat     int64
+		sends    int
+		lastSent string
+	}{
+		{1, 1, "2026-10-15"}, // 发送成功，记下补发的那一期
+		{2, 2, ""},           // 临时失败，下一分钟重试
+		{3, 1, "2026-10-15"}, // 永久失败也记为已处理
+		{4, 0, ""},
+	}
+	for _, tt := range tests {
+		subs, err := s.ByChat(tt.chat)
+		if err != nil || len(subs) != 1 {
+			t.Fatalf("ByChat(%d) = %v, %v", tt.chat, subs, err)
+		}
+		if sent[tt.chat] != tt.sends || subs[0].LastSent != tt.lastSent {
+			t.Errorf("chat %d: %d sends, LastSent %q, want %d %q", tt.chat, sent[tt.chat], subs[0].LastSent, tt.sends, tt.lastSent)
+		}
+	}
+}
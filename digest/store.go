@@ -0,0 +1,95 @@
+package digest
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"aki.telegram.bot.fxrate/bucket"
+)
+
+// MaxPerChat 每个会话最多的订阅数
+const MaxPerChat = 10
+
+// MaxCurrencies 每个订阅最多的币种数
+const MaxCurrencies = 8
+
+// ErrNotFound 订阅不存在（或已被删除）
+var ErrNotFound = bucket.ErrNotFound
+
+// ErrTooMany 会话内订阅数已达上限
+var ErrTooMany = fmt.Errorf("digest: at most %d subscriptions per chat", MaxPerChat)
+
+// Subscription 一条定时汇总订阅
+type Subscription struct {
+	ID         uint64    `json:"id"`
+	ChatID     int64     `json:"chat_id"`
+	ThreadID   int       `json:"thread_id,omitempty"`
+	UserID     int64     `json:"user_id"`
+	Currencies []string  `json:"currencies"`
+	Minute     int       `json:"minute"`    // 发送时间，Asia/Shanghai 当天第几分钟
+	Everyday   bool      `json:"everyday"`  // false 时只在工作日发送
+	LastSent   string    `json:"last_sent"` // 最近发送的那一期的日期 2006-01-02，重启后据此不重发、不漏发
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Clock 发送时间 "09:30"
+func (s *Subscription) Clock() string {
+	return fmt.Sprintf("%02d:%02d", s.Minute/60, s.Minute%60)
+}
+
+// Store 订阅存储
+type Store struct {
+	items *bucket.Store[Subscription]
+}
+
+var defaultStore atomic.Pointer[Store]
+
+// SetDefault 设置全局订阅存储
+func SetDefault(s *Store) { defaultStore.Store(s) }
+
+// Default 全局订阅存储，未启用时为 nil
+func Default() *Store { return defaultStore.Load() }
+
+// New 在 db 中建好订阅所需的 bucket
+func New(db *bolt.DB) (*Store, error) {
+	items, err := bucket.New[Subscription](db, "digests")
+	if err != nil {
+		return nil, err
+	}
+	return &Store{items: items}, nil
+}
+
+// Add 保存新订阅并分配 ID
+func (s *Store) Add(sub *Subscription) error {
+	if sub.CreatedAt.IsZero() {
+		sub.CreatedAt = time.Now()
+	}
+	err := s.items.Insert(sub, func(id uint64) { sub.ID = id }, MaxPerChat, func(o *Subscription) bool { return o.ChatID == sub.ChatID })
+	if errors.Is(err, bucket.ErrLimit) {
+		return ErrTooMany
+	}
+	return err
+}
+
+// Update 覆盖已有订阅；订阅已被删除时返回 ErrNotFound，不会重新写回
+func (s *Store) Update(sub *Subscription) error { return s.items.Update(sub.ID, sub) }
+
+// Get 按 ID 取订阅
+func (s *Store) Get(id uint64) (*Subscription, error) { return s.items.Get(id) }
+
+// Delete 删除订阅
+func (s *Store) Delete(id uint64) error { return s.items.Delete(id) }
+
+// All 全部订阅，按 ID 升序
+func (s *Store) All() ([]Subscription, error) {
+	return s.items.Filter(func(*Subscription) bool { return true })
+}
+
+// ByChat 某会话的订阅，按 ID 升序
+func (s *Store) ByChat(chatID int64) ([]Subscription, error) {
+	return s.items.Filter(func(sub *Subscription) bool { return sub.ChatID == chatID })
+}
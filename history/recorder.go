@@ -0,0 +1,51 @@
+package history
+
+import (
+	"context"
+	"time"
+
+	"aki.telegram.bot.fxrate/bank"
+	"aki.telegram.bot.fxrate/tools"
+)
+
+// DefaultInterval 默认记录间隔
+const DefaultInterval = 10 * time.Minute
+
+// Run 每隔 interval 抓取所有来源的整表写入 s，直到 ctx 结束；启动时立即记录一次。
+// 抓取走 bank 的缓存，间隔短于缓存时长时只会重复写入同一份快照（被去重跳过）。
+func Run(ctx context.Context, s *Store, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		recordAll(ctx, s)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// recordAll 逐个来源记录一次；单个来源失败只记日志
+func recordAll(ctx context.Context, s *Store) {
+	total := 0
+	for _, p := range bank.Providers() {
+		snap, err := p.Snapshot(ctx)
+		if err != nil {
+			tools.LogError("history: %s 获取失败: %v", p.Name(), err)
+			continue
+		}
+		n, err := s.Save(snap)
+		if err != nil {
+			tools.LogError("history: %s 写入失败: %v", p.Name(), err)
+			continue
+		}
+		total += n
+	}
+	if total > 0 {
+		tools.LogInfo("history: 新增 %d 条牌价记录", total)
+	}
+}
@@ -0,0 +1,74 @@
+package history
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"aki.telegram.bot.fxrate/bank"
+)
+
+// Bar 某一天某项牌价的开高低收（每100外币）
+type Bar struct {
+	Day                    time.Time // 当天 0 点（Asia/Shanghai）
+	Open, High, Low, Close decimal.Decimal
+	Valid                  bool // 当天是否有过该项报价；为 false 时开高低收无意义
+	Count                  int  // 当天银行发布的次数（含该项缺价的发布）
+}
+
+// Point 某次发布的某项牌价（每100外币）
+type Point struct {
+	Time  time.Time
+	Value decimal.Decimal
+}
+
+// Series 取出 records 中某项牌价的序列，缺价的发布跳过
+func Series(records []Record, f bank.Field) []Point {
+	out := make([]Point, 0, len(records))
+	for i := range records {
+		if v := records[i].Quote().Per(f, 100); v.Valid {
+			out = append(out, Point{Time: records[i].ReleaseTime, Value: v.Decimal})
+		}
+	}
+	return out
+}
+
+// Daily 按 Asia/Shanghai 自然日汇总 records（须按时间升序）中某项牌价
+func Daily(records []Record, f bank.Field) []Bar {
+	var out []Bar
+	for i := range records {
+		r := &records[i]
+		t := r.ReleaseTime.In(bank.Shanghai)
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, bank.Shanghai)
+		if len(out) == 0 || !out[len(out)-1].Day.Equal(day) {
+			out = append(out, Bar{Day: day})
+		}
+		bar := &out[len(out)-1]
+		bar.Count++
+		v := r.Quote().Per(f, 100)
+		if !v.Valid {
+			continue
+		}
+		if !bar.Valid {
+			bar.Open, bar.High, bar.Low, bar.Valid = v.Decimal, v.Decimal, v.Decimal, true
+		}
+		bar.High = decimal.Max(bar.High, v.Decimal)
+		bar.Low = decimal.Min(bar.Low, v.Decimal)
+		bar.Close = v.Decimal
+	}
+	return out
+}
+
+// Best 序列中的最优点：higher 为 true 取最高（买入价），否则取最低（卖出价）；相同取最早
+func Best(points []Point, higher bool) (Point, bool) {
+	if len(points) == 0 {
+		return Point{}, false
+	}
+	best := points[0]
+	for _, p := range points[1:] {
+		if (higher && p.Value.GreaterThan(best.Value)) || (!higher && p.Value.LessThan(best.Value)) {
+			best = p
+		}
+	}
+	return best, true
+}
@@ -0,0 +1,127 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"aki.telegram.bot.fxrate/bank"
+)
+
+// rec 一条记录：发布时间为 t0 之后 h 小时，现汇买入价 buy（空为缺价），现汇卖出价固定
+func rec(h int, buy string) Record {
+	return Record{Bank: "boc", Code: "USD", Unit: 100,
+		BuySpot: price(buy), SellSpot: price("713.00"),
+		ReleaseTime: t0.Add(time.Duration(h) * time.Hour)}
+}
+
+func TestDaily(t *testing.T) {
+	// t0 为 3 月 2 日 09:30（Asia/Shanghai）
+	records := []Record{
+		rec(0, "710.00"),
+		rec(1, "711.50"),
+		rec(2, ""), // 当天有一次发布缺这一项
+		rec(3, "709.80"),
+		rec(4, "710.20"),
+		rec(24, ""), // 3 月 3 日只有缺价的发布
+		rec(25, ""),
+		rec(48, "0"), // 3 月 4 日价格恰好是 0，也算有报价
+		rec(49, "712.00"),
+	}
+	bars := Daily(records, bank.BuySpot)
+
+	type want struct {
+		day                    string
+		valid                  bool
+		open, high, low, close string
+		count                  int
+	}
+	wants := []want{
+		{"2026-03-02", true, "710", "711.5", "709.8", "710.2", 5},
+		{"2026-03-03", false, "", "", "", "", 2},
+		{"2026-03-04", true, "0", "712", "0", "712", 2},
+	}
+	if len(bars) != len(wants) {
+		t.Fatalf("Daily returned %d bars, want %d", len(bars), len(wants))
+	}
+	for i, w := range wants {
+		b := bars[i]
+		if got := b.Day.Format(time.DateOnly); got != w.day || b.Valid != w.valid || b.Count != w.count {
+			t.Errorf("bar %d = %s valid %v count %d, want %s valid %v count %d", i, got, b.Valid, b.Count, w.day, w.valid, w.count)
+			continue
+		}
+		if !w.valid {
+			continue
+		}
+		for _, c := range []struct {
+			name string
+			got  decimal.Decimal
+			want string
+		}{{"open", b.Open, w.open}, {"high", b.High, w.high}, {"low", b.Low, w.low}, {"close", b.Close, w.close}} {
+			if !c.got.Equal(decimal.RequireFromString(c.want)) {
+				t.Errorf("bar %s %s = %s, want %s", w.day, c.name, c.got, c.want)
+			}
+		}
+	}
+}
+
+func TestDailyDayBoundary(t *testing.T) {
+	// 上海时间 23:59 与次日 00:01 分属两天，即使 UTC 下是同一天
+	late := time.Date(2026, 3, 2, 23, 59, 0, 0, bank.Shanghai)
+	records := []Record{
+		{Code: "USD", Unit: 100, BuySpot: price("710"), ReleaseTime: late.UTC()},
+		{Code: "USD", Unit: 100, BuySpot: price("711"), ReleaseTime: late.Add(2 * time.Minute).UTC()},
+	}
+	bars := Daily(records, bank.BuySpot)
+	if len(bars) != 2 || bars[0].Day.Format(time.DateOnly) != "2026-03-02" || bars[1].Day.Format(time.DateOnly) != "2026-03-03" {
+		t.Errorf("bars = %+v", bars)
+	}
+}
+
+func TestDailyPer100(t *testing.T) {
+	// 每 1 外币报价的来源按每100外币汇总
+	records := []Record{{Code: "USD", Unit: 1, BuySpot: price("7.1"), ReleaseTime: t0}}
+	bars := Daily(records, bank.BuySpot)
+	if len(bars) != 1 || !bars[0].Close.Equal(decimal.NewFromInt(710)) {
+		t.Errorf("bars = %+v", bars)
+	}
+}
+
+func TestSeries(t *testing.T) {
+	points := Series([]Record{rec(0, "710"), rec(1, ""), rec(2, "711")}, bank.BuySpot)
+	if len(points) != 2 || !points[1].Time.Equal(t0.Add(2*time.Hour)) {
+		t.Errorf("Series = %+v, want the missing price skipped", points)
+	}
+}
+
+func TestBest(t *testing.T) {
+	pt := func(h int, v string) Point {
+		return Point{Time: t0.Add(time.Duration(h) * time.Hour), Value: decimal.RequireFromString(v)}
+	}
+	tests := []struct {
+		name   string
+		points []Point
+		higher bool
+		want   int // 期望命中的下标，-1 表示没有
+	}{
+		{"空序列", nil, true, -1},
+		{"取最高", []Point{pt(0, "710"), pt(1, "712"), pt(2, "711")}, true, 1},
+		{"取最低", []Point{pt(0, "710"), pt(1, "712"), pt(2, "709.9")}, false, 2},
+		{"最高有并列时取最早", []Point{pt(0, "710"), pt(1, "712"), pt(2, "712")}, true, 1},
+		{"最低有并列时取最早", []Point{pt(0, "709"), pt(1, "712"), pt(2, "709")}, false, 0},
+		{"单点", []Point{pt(0, "710")}, false, 0},
+	}
+	for _, tt := range tests {
+		got, ok := Best(tt.points, tt.higher)
+		if tt.want < 0 {
+			if ok {
+				t.Errorf("%s: Best = %+v, want none", tt.name, got)
+			}
+			continue
+		}
+		if w := tt.points[tt.want]; !ok || !got.Time.Equal(w.Time) || !got.Value.Equal(w.Value) {
+			t.Errorf("%s: Best = %+v, %v, want %+v", tt.name, got, ok, w)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package history
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/shopspring/decimal"
+	bolt "go.etcd.io/bbolt"
+
+	"aki.telegram.bot.fxrate/bank"
+)
+
+// 库结构：rates / <bank> / <币种> / <发布时间（秒，大端）> -> Record(JSON)
+var ratesBucket = []byte("rates")
+
+// Record 某来源某币种的一次发布
+type Record struct {
+	Bank        string              `json:"bank"`
+	Code        string              `json:"code"`
+	Unit        int64               `json:"unit"`
+	BuySpot     decimal.NullDecimal `json:"buy_spot"`
+	BuyCash     decimal.NullDecimal `json:"buy_cash"`
+	SellSpot    decimal.NullDecimal `json:"sell_spot"`
+	SellCash    decimal.NullDecimal `json:"sell_cash"`
+	Middle      decimal.NullDecimal `json:"middle"`
+	ReleaseTime time.Time           `json:"release_time"` // 银行发布时间；来源没有时为首次抓到的时间
+	FetchedAt   time.Time           `json:"fetched_at"`
+}
+
+// Quote 还原为 bank.Quote，便于复用 Per、Get 等方法
+func (r *Record) Quote() *bank.Quote {
+	return &bank.Quote{
+		Code:        r.Code,
+		Unit:        r.Unit,
+		BuySpot:     r.BuySpot,
+		BuyCash:     r.BuyCash,
+		SellSpot:    r.SellSpot,
+		SellCash:    r.SellCash,
+		Middle:      r.Middle,
+		ReleaseTime: r.ReleaseTime,
+	}
+}
+
+// samePrices 两次记录的价格完全一致
+func (r *Record) samePrices(o *Record) bool {
+	eq := func(a, b decimal.NullDecimal) bool {
+		return a.Valid == b.Valid && (!a.Valid || a.Decimal.Equal(b.Decimal))
+	}
+	return r.Unit == o.Unit && eq(r.BuySpot, o.BuySpot) && eq(r.BuyCash, o.BuyCash) &&
+		eq(r.SellSpot, o.SellSpot) && eq(r.SellCash, o.SellCash) && eq(r.Middle, o.Middle)
+}
+
+// Store 基于 bbolt 的牌价历史库
+type Store struct {
+	db *bolt.DB
+}
+
+var defaultStore atomic.Pointer[Store]
+
+// SetDefault 设置全局历史库，命令与告警等从 Default 取用
+func SetDefault(s *Store) { defaultStore.Store(s) }
+
+// Default 全局历史库，未启用时为 nil
+func Default() *Store { return defaultStore.Load() }
+
+// Open 打开（不存在则创建）历史库
+func Open(path string) (*Store, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(ratesBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Close 关闭历史库
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// DB 底层 bbolt 句柄，供同库存放其它数据（告警、订阅等）的包使用
+func (s *Store) DB() *bolt.DB {
+	return s.db
+}
+
+func timeKey(t time.Time) []byte {
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, uint64(t.Unix()))
+	return k
+}
+
+// Save 写入一份快照，返回新增的记录数。
+// 同一发布时间已记录过的跳过；来源不给发布时间时，价格与上一条相同也跳过，
+// 变了才以抓取时间作为发布时间记一条。
+func (s *Store) Save(snap *bank.Snapshot) (int, error) {
+	added := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bb, err := tx.Bucket(ratesBucket).CreateBucketIfNotExists([]byte(snap.Bank))
+		if err != nil {
+			return err
+		}
+		for i := range snap.Quotes {
+			q := &snap.Quotes[i]
+			if q.Code == "" {
+				continue
+			}
+			rec := &Record{
+				Bank:        snap.Bank,
+				Code:        q.Code,
+				Unit:        q.Unit,
+				BuySpot:     q.BuySpot,
+				BuyCash:     q.BuyCash,
+				SellSpot:    q.SellSpot,
+				SellCash:    q.SellCash,
+				Middle:      q.Middle,
+				ReleaseTime: q.ReleaseTime,
+				FetchedAt:   snap.FetchedAt,
+			}
+			if rec.ReleaseTime.IsZero() {
+				rec.ReleaseTime = snap.ReleaseTime
+			}
+			cb, err := bb.CreateBucketIfNotExists([]byte(q.Code))
+			if err != nil {
+				return err
+			}
+			if rec.ReleaseTime.IsZero() {
+				if _, v := cb.Cursor().Last(); v != nil {
+					var last Record
+					if json.Unmarshal(v, &last) == nil && last.samePrices(rec) {
+						continue
+					}
+				}
+				rec.ReleaseTime = snap.FetchedAt
+			}
+			key := timeKey(rec.ReleaseTime)
+			if cb.Get(key) != nil {
+				continue
+			}
+			data, err := json.Marshal(rec)
+			if err != nil {
+				return err
+			}
+			if err := cb.Put(key, data); err != nil {
+				return err
+			}
+			added++
+		}
+		return nil
+	})
+	return added, err
+}
+
+// Range 取某来源某币种在 [from, to) 内发布的记录，按发布时间升序
+func (s *Store) Range(bankKey, code string, from, to time.Time) ([]Record, error) {
+	var out []Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bb := tx.Bucket(ratesBucket).Bucket([]byte(bankKey))
+		if bb == nil {
+			return nil
+		}
+		cb := bb.Bucket([]byte(code))
+		if cb == nil {
+			return nil
+		}
+		end := timeKey(to)
+		c := cb.Cursor()
+		for k, v := c.Seek(timeKey(from)); k != nil && string(k) < string(end); k, v = c.Next() {
+			var r Record
+			if err := json.Unmarshal(v, &r); err != nil {
+				return fmt.Errorf("history: decode %s/%s: %w", bankKey, code, err)
+			}
+			out = append(out, r)
+		}
+		return nil
+	})
+	return out, err
+}
+
+// ErrNoRecord 没有任何记录
+var ErrNoRecord = errors.New("history: no record")
+
+// Latest 某来源某币种最近一次记录
+func (s *Store) Latest(bankKey, code string) (*Record, error) {
+	var out *Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bb := tx.Bucket(ratesBucket).Bucket([]byte(bankKey))
+		if bb == nil {
+			return ErrNoRecord
+		}
+		cb := bb.Bucket([]byte(code))
+		if cb == nil {
+			return ErrNoRecord
+		}
+		_, v := cb.Cursor().Last()
+		if v == nil {
+			return ErrNoRecord
+		}
+		out = new(Record)
+		return json.Unmarshal(v, out)
+	})
+	return out, err
+}
@@ -0,0 +1,150 @@
+package history
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"aki.telegram.bot.fxrate/bank"
+)
+
+func openTemp(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "sub", "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func price(s string) decimal.NullDecimal {
+	if s == "" {
+		return decimal.NullDecimal{}
+	}
+	return decimal.NewNullDecimal(decimal.RequireFromString(s))
+}
+
+var t0 = time.Date(2026, 3, 2, 9, 30, 0, 0, bank.Shanghai)
+
+func snapshot(fetched, release time.Time, buy string) *bank.Snapshot {
+	return &bank.Snapshot{
+		Bank:      "boc",
+		FetchedAt: fetched,
+		Quotes: []bank.Quote{
+			{Code: "USD", Unit: 100, BuySpot: price(buy), SellSpot: price("713.00"), ReleaseTime: release},
+			{Name: "未识别币种", Unit: 100, BuySpot: price("1")}, // 没有代码的行不记录
+		},
+	}
+}
+
+func TestSaveDedup(t *testing.T) {
+	s := openTemp(t)
+	noTime := time.Time{}
+	tests := []struct {
+		name string
+		snap *bank.Snapshot
+		want int
+	}{
+		{"首次发布", snapshot(t0, t0, "710.00"), 1},
+		{"同一发布时间再抓一次", snapshot(t0.Add(time.Minute), t0, "710.00"), 0},
+		{"新的发布时间", snapshot(t0.Add(2*time.Minute), t0.Add(time.Minute), "710.50"), 1},
+		{"没有发布时间但价格没变", snapshot(t0.Add(3*time.Minute), noTime, "710.50"), 0},
+		{"没有发布时间且价格变了", snapshot(t0.Add(4*time.Minute), noTime, "711.00"), 1},
+		{"没有发布时间又没变", snapshot(t0.Add(5*time.Minute), noTime, "711.00"), 0},
+	}
+	for _, tt := range tests {
+		n, err := s.Save(tt.snap)
+		if err != nil || n != tt.want {
+			t.Errorf("%s: Save = %d, %v, want %d", tt.name, n, err, tt.want)
+		}
+	}
+
+	recs, err := s.Range("boc", "USD", t0, t0.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 3 {
+		t.Fatalf("Range returned %d records, want 3", len(recs))
+	}
+	// 没有发布时间的那条以抓取时间记
+	if last := recs[2]; !last.ReleaseTime.Equal(t0.Add(4*time.Minute)) || !last.BuySpot.Decimal.Equal(decimal.RequireFromString("711")) {
+		t.Errorf("last record = %s %s", last.ReleaseTime, last.BuySpot.Decimal)
+	}
+}
+
+func TestSaveSnapshotReleaseTime(t *testing.T) {
+	s := openTemp(t)
+	snap := snapshot(t0.Add(time.Minute), time.Time{}, "710.00")
+	snap.ReleaseTime = t0 // 行上没有发布时间时用整表的
+	if n, err := s.Save(snap); err != nil || n != 1 {
+		t.Fatalf("Save = %d, %v", n, err)
+	}
+	r, err := s.Latest("boc", "USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.ReleaseTime.Equal(t0) || !r.FetchedAt.Equal(t0.Add(time.Minute)) {
+		t.Errorf("ReleaseTime = %s, FetchedAt = %s", r.ReleaseTime, r.FetchedAt)
+	}
+}
+
+func TestRangeOrder(t *testing.T) {
+	s := openTemp(t)
+	// 故意乱序写入；+1s 与 +256s 在小端序下会排反
+	offsets := []time.Duration{256 * time.Second, time.Second, 0, 65536 * time.Second, 255 * time.Second}
+	for _, off := range offsets {
+		if _, err := s.Save(snapshot(t0.Add(off), t0.Add(off), "710.00")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     []time.Duration
+	}{
+		{"全部按时间升序", t0, t0.Add(24 * time.Hour), []time.Duration{0, time.Second, 255 * time.Second, 256 * time.Second, 65536 * time.Second}},
+		{"右端开区间", t0, t0.Add(256 * time.Second), []time.Duration{0, time.Second, 255 * time.Second}},
+		{"左端闭区间", t0.Add(time.Second), t0.Add(time.Hour), []time.Duration{time.Second, 255 * time.Second, 256 * time.Second}},
+		{"区间内没有记录", t0.Add(time.Hour), t0.Add(2 * time.Hour), nil},
+	}
+	for _, tt := range tests {
+		recs, err := s.Range("boc", "USD", tt.from, tt.to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(recs) != len(tt.want) {
+			t.Errorf("%s: %d records, want %d", tt.name, len(recs), len(tt.want))
+			continue
+		}
+		for i, r := range recs {
+			if !r.ReleaseTime.Equal(t0.Add(tt.want[i])) {
+				t.Errorf("%s: record %d at %s, want %s", tt.name, i, r.ReleaseTime, t0.Add(tt.want[i]))
+			}
+		}
+	}
+
+	r, err := s.Latest("boc", "USD")
+	if err != nil || !r.ReleaseTime.Equal(t0.Add(65536*time.Second)) {
+		t.Errorf("Latest = %v, %v", r, err)
+	}
+}
+
+func TestMissingBucket(t *testing.T) {
+	s := openTemp(t)
+	if _, err := s.Save(snapshot(t0, t0, "710.00")); err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range [][2]string{{"cmb", "USD"}, {"boc", "JPY"}} {
+		if recs, err := s.Range(k[0], k[1], t0, t0.Add(time.Hour)); err != nil || recs != nil {
+			t.Errorf("Range(%s, %s) = %v, %v", k[0], k[1], recs, err)
+		}
+		if _, err := s.Latest(k[0], k[1]); !errors.Is(err, ErrNoRecord) {
+			t.Errorf("Latest(%s, %s) error = %v, want ErrNoRecord", k[0], k[1], err)
+		}
+	}
+}
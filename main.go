@@ -5,7 +5,14 @@ import (
 	"os"
 	"os/signal"
 	"strings"
+	"time"
 
+	"aki.telegram.bot.fxrate/alert"
+	"aki.telegram.bot.fxrate/bank"
+	"aki.telegram.bot.fxrate/commands"
+	"aki.telegram.bot.fxrate/digest"
+	"aki.telegram.bot.fxrate/history"
+	"aki.telegram.bot.fxrate/settings"
 	"aki.telegram.bot.fxrate/tools"
 	"github.com/go-telegram/bot"
 	"github.com/joho/godotenv"
@@ -29,9 +36,41 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err := bank.ConfigureCache(os.Getenv("FXRATE_CACHE_TTL")); err != nil {
+		tools.LogError("FXRATE_CACHE_TTL 配置有误，使用默认缓存时长: %v", err)
+	}
+
+	if err := bank.LoadProgrammes(os.Getenv("FXRATE_PROGRAMMES")); err != nil {
+		tools.LogError("加载优惠方案失败，出错及之后的方案不会生效: %v", err)
+	}
+
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer cancel()
 
+	// 价格提醒、定时汇总与会话设置和牌价历史同库；关闭历史记录只停记录，不影响其它功能
+	if store, record := openStore(); store != nil {
+		defer store.Close()
+		if record {
+			history.SetDefault(store)
+			go history.Run(ctx, store, envInterval("FXRATE_HISTORY_INTERVAL", history.DefaultInterval))
+		}
+		if alerts, err := alert.New(store.DB()); err != nil {
+			tools.LogError("初始化价格提醒失败: %v", err)
+		} else {
+			alert.SetDefault(alerts)
+		}
+		if digests, err := digest.New(store.DB()); err != nil {
+			tools.LogError("初始化定时汇总失败: %v", err)
+		} else {
+			digest.SetDefault(digests)
+		}
+		if chats, err := settings.New(store.DB()); err != nil {
+			tools.LogError("初始化会话设置失败: %v", err)
+		} else {
+			settings.SetDefault(chats)
+		}
+	}
+
 	opts := []bot.Option{
 		bot.WithDefaultHandler(HandleCommand),
 	}
@@ -42,5 +81,50 @@ func main() {
 	} else {
 		tools.LogInfo("Bot 创建完毕")
 	}
+	if alerts := alert.Default(); alerts != nil {
+		go alert.Run(ctx, alerts, envInterval("FXRATE_ALERT_INTERVAL", alert.DefaultInterval), commands.AlertNotifier(b))
+	}
+	if digests := digest.Default(); digests != nil {
+		cal, err := digest.LoadCalendar(os.Getenv("FXRATE_HOLIDAYS"))
+		if err != nil {
+			tools.LogError("加载节假日日历失败，只按周末判断工作日: %v", err)
+		}
+		go digest.Run(ctx, digests, cal, commands.DigestSender(b))
+	}
 	b.Start(ctx)
 }
+
+// openStore 打开数据库（默认 data/history.db）。FXRATE_HISTORY_DB=off 时仍打开默认路径，
+// 只是 record 为 false、不记录牌价历史；打不开时返回 nil，依赖数据库的命令都会提示未启用
+func openStore() (store *history.Store, record bool) {
+	path := strings.TrimSpace(os.Getenv("FXRATE_HISTORY_DB"))
+	record = path != "off"
+	if path == "" || path == "off" {
+		path = "data/history.db"
+	}
+	store, err := history.Open(path)
+	if err != nil {
+		tools.LogError("打开数据库 %s 失败，牌价历史、价格提醒、定时汇总与会话设置均不可用: %v", path, err)
+		return nil, false
+	}
+	if record {
+		tools.LogInfo("牌价历史记录到 %s", path)
+	} else {
+		tools.LogInfo("未启用牌价历史记录，数据库 %s 仅用于提醒、汇总与会话设置", path)
+	}
+	return store, record
+}
+
+// envInterval 读取形如 "10m" 的间隔配置，未配置或有误（短于 1m）时用 def
+func envInterval(name string, def time.Duration) time.Duration {
+	s := strings.TrimSpace(os.Getenv(name))
+	if s == "" {
+		return def
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < time.Minute {
+		tools.LogError("%s 配置有误（至少 1m），使用默认 %s", name, def)
+		return def
+	}
+	return d
+}
@@ -0,0 +1,102 @@
+package money
+
+import (
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"aki.telegram.bot.fxrate/currency"
+)
+
+// RoundingMode 舍入方式
+type RoundingMode int
+
+const (
+	HalfUp   RoundingMode = iota // 四舍五入（银行 App 展示金额的常见做法）
+	HalfEven                     // 银行家舍入（四舍六入五成双）
+	Down                         // 截断，向零舍入
+	Up                           // 进位，远离零舍入
+)
+
+// RateDigits 派生牌价（中间价、优惠价等）保留的小数位
+const RateDigits = 4
+
+// divPrecision 除法的中间精度，远高于任何币种的最小单位
+const divPrecision = 16
+
+// MinorUnits 返回币种的小数位数，未知币种按 2 位
+func MinorUnits(code string) int32 {
+	if c, ok := currency.Lookup(code); ok {
+		return c.Minor
+	}
+	return 2
+}
+
+// RoundTo 按指定小数位与舍入方式舍入
+func RoundTo(d decimal.Decimal, places int32, mode RoundingMode) decimal.Decimal {
+	switch mode {
+	case HalfEven:
+		return d.RoundBank(places)
+	case Down:
+		return d.Truncate(places)
+	case Up:
+		return d.RoundUp(places)
+	default:
+		return d.Round(places)
+	}
+}
+
+// Round 按币种最小单位舍入
+func Round(d decimal.Decimal, code string, mode RoundingMode) decimal.Decimal {
+	return RoundTo(d, MinorUnits(code), mode)
+}
+
+// Format 按币种最小单位输出金额（HalfUp）
+func Format(d decimal.Decimal, code string) string {
+	n := MinorUnits(code)
+	return Round(d, code, HalfUp).StringFixed(n)
+}
+
+// FormatRate 输出牌价，保留来源原有精度，不补零
+func FormatRate(d decimal.Decimal) string {
+	return d.String()
+}
+
+// ParseAmount 解析金额，允许千分位逗号
+func ParseAmount(s string) (decimal.Decimal, bool) {
+	s = strings.TrimSpace(strings.ReplaceAll(s, ",", ""))
+	if s == "" {
+		return decimal.Zero, false
+	}
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return decimal.Zero, false
+	}
+	return d, true
+}
+
+// FXToCNY 外币金额按“每 unit 外币 = rate 人民币”折算为人民币，按人民币最小单位舍入
+func FXToCNY(amount, rate decimal.Decimal, unit int64, mode RoundingMode) decimal.Decimal {
+	if unit <= 0 {
+		unit = 100
+	}
+	out := amount.Mul(rate).DivRound(decimal.NewFromInt(unit), divPrecision)
+	return Round(out, "CNY", mode)
+}
+
+// CNYToFX 人民币金额按“每 unit 外币 = rate 人民币”折算为外币 code，按该币种最小单位舍入
+func CNYToFX(amount, rate decimal.Decimal, unit int64, code string, mode RoundingMode) decimal.Decimal {
+	if unit <= 0 {
+		unit = 100
+	}
+	if rate.IsZero() {
+		return decimal.Zero
+	}
+	out := amount.Mul(decimal.NewFromInt(unit)).DivRound(rate, divPrecision)
+	return Round(out, code, mode)
+}
+
+// Mid 买卖价的中间价
+func Mid(buy, sell decimal.Decimal) decimal.Decimal {
+	return buy.Add(sell).DivRound(decimal.NewFromInt(2), divPrecision)
+}
@@ -0,0 +1,54 @@
+package settings
+
+import (
+	"sync/atomic"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"aki.telegram.bot.fxrate/bucket"
+)
+
+// ErrNotFound 会话还没有保存过设置
+var ErrNotFound = bucket.ErrNotFound
+
+// Chat 单个会话的设置
+type Chat struct {
+	ChatID    int64     `json:"chat_id"`
+	Natural   bool      `json:"natural"` // 是否识别 "100usd" 这类不带命令的换算
+	UpdatedBy int64     `json:"updated_by"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Store 会话设置存储，以会话 ID 为键
+type Store struct {
+	items *bucket.Store[Chat]
+}
+
+var defaultStore atomic.Pointer[Store]
+
+// SetDefault 设置全局会话设置存储
+func SetDefault(s *Store) { defaultStore.Store(s) }
+
+// Default 全局会话设置存储，未启用时为 nil
+func Default() *Store { return defaultStore.Load() }
+
+// New 在 db 中建好会话设置所需的 bucket
+func New(db *bolt.DB) (*Store, error) {
+	items, err := bucket.New[Chat](db, "chats")
+	if err != nil {
+		return nil, err
+	}
+	return &Store{items: items}, nil
+}
+
+// Get 取会话设置，没有保存过时返回 ErrNotFound
+func (s *Store) Get(chatID int64) (*Chat, error) { return s.items.Get(uint64(chatID)) }
+
+// Put 保存会话设置
+func (s *Store) Put(c *Chat) error {
+	if c.UpdatedAt.IsZero() {
+		c.UpdatedAt = time.Now()
+	}
+	return s.items.Put(uint64(c.ChatID), c)
+}
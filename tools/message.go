@@ -113,3 +113,83 @@ func DeleteMessage(ctx context.Context, b *bot.Bot, chatID int64, messageID int)
 	}
 	return nil
 }
+
+// SendMessageWithMarkup 发送带键盘（如 InlineKeyboardMarkup）的消息
+func SendMessageWithMarkup(ctx context.Context, b *bot.Bot, chatID int64, message string, messageThreadID int, parseMode string, markup models.ReplyMarkup) (int, error) {
+	params := &bot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        message,
+		ParseMode:   parseModeFromString(parseMode),
+		ReplyMarkup: markup,
+	}
+	if messageThreadID > 0 {
+		params.MessageThreadID = messageThreadID
+	}
+
+	msg, err := b.SendMessage(ctx, params)
+	if err != nil {
+		LogError("Error sending message: %v", err)
+		return 0, err
+	}
+	return msg.ID, nil
+}
+
+// EditMessageText 修改已发送消息的文本与键盘，markup 为 nil 时移除键盘
+func EditMessageText(ctx context.Context, b *bot.Bot, chatID int64, messageID int, message string, parseMode string, markup models.ReplyMarkup) error {
+	params := &bot.EditMessageTextParams{
+		ChatID:      chatID,
+		MessageID:   messageID,
+		Text:        message,
+		ParseMode:   parseModeFromString(parseMode),
+		ReplyMarkup: markup,
+	}
+	_, err := b.EditMessageText(ctx, params)
+	if err != nil {
+		LogError("Error editing message: %v", err)
+		return err
+	}
+	return nil
+}
+
+// AnswerCallbackQuery 应答按钮回调，text 非空时以浮动提示显示
+func AnswerCallbackQuery(ctx context.Context, b *bot.Bot, callbackQueryID string, text string) {
+	_, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{
+		CallbackQueryID: callbackQueryID,
+		Text:            text,
+	})
+	if err != nil {
+		LogError("Error answering callback query: %v", err)
+	}
+}
+
+// SendPhoto 以图片形式发送内存中的 PNG/JPEG，caption 为图片说明
+func SendPhoto(ctx context.Context, b *bot.Bot, chatID int64, messageThreadID int, filename string, data []byte, caption string, parseMode string) (int, error) {
+	params := &bot.SendPhotoParams{
+		ChatID:    chatID,
+		Photo:     &models.InputFileUpload{Filename: filename, Data: bytes.NewReader(data)},
+		Caption:   caption,
+		ParseMode: parseModeFromString(parseMode),
+	}
+	if messageThreadID > 0 {
+		params.MessageThreadID = messageThreadID
+	}
+
+	msg, err := b.SendPhoto(ctx, params)
+	if err != nil {
+		LogError("Error sending photo: %v", err)
+		return 0, err
+	}
+	return msg.ID, nil
+}
+
+// AnswerInlineQuery 回复内联查询，cacheTime 为 Telegram 端缓存结果的秒数
+func AnswerInlineQuery(ctx context.Context, b *bot.Bot, inlineQueryID string, results []models.InlineQueryResult, cacheTime int) {
+	_, err := b.AnswerInlineQuery(ctx, &bot.AnswerInlineQueryParams{
+		InlineQueryID: inlineQueryID,
+		Results:       results,
+		CacheTime:     cacheTime,
+	})
+	if err != nil {
+		LogError("Error answering inline query: %v", err)
+	}
+}